@@ -0,0 +1,5 @@
+// Package enrichmentv1 holds the gRPC service of the product ingestion and enrichments along with its
+// protobuf messages. The code is generated from enrichment.proto with protoc-gen-go and protoc-gen-go-grpc.
+package enrichmentv1
+
+//go:generate protoc --proto_path=../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative api/enrichment/v1/enrichment.proto
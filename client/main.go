@@ -119,7 +119,7 @@ func readProductFromJsonAndSaveToFirestore(ctx context.Context, productRepo prod
 	}
 
 	// Save product to Firestore
-	err = productRepo.Create(ctx, product)
+	_, err = productRepo.Create(ctx, product)
 	if err != nil {
 		fmt.Println("Error saving product to Firestore:", err)
 		return err
@@ -3,6 +3,66 @@ export GILAS_API_KEY=
 export GILAS_API_URL=https://api.gilas.io/v1
 export GILAS_GPT_MODEL=gpt-3.5-turbo
 
+# LLM Providers Configuration (gilas, openai, anthropic or ollama)
+export LLM_SENTIMENT_PROVIDER=gilas
+export LLM_VIDEOS_PROVIDER=gilas
+export LLM_REQUEST_TIMEOUT=2m
+# Per-task fallback chains (task=provider/model|provider/model) and the default fallback chain
+export LLM_TASK_MODELS=
+export LLM_FALLBACK_CHAIN=
+# Rate limits of each LLM provider, 0 disables the limit
+export LLM_REQUESTS_PER_MINUTE=0
+export LLM_TOKENS_PER_MINUTE=0
+
+# LLM response cache, one of none, memory, disk or database
+export LLM_CACHE_STORE=none
+export LLM_CACHE_DIR=.cache/llm
+export LLM_CACHE_TTL=168h
+
+# LLM usage, prices as model=prompt/completion in USD per 1M tokens and an optional monthly budget in USD
+export LLM_PRICES=
+export LLM_MONTHLY_BUDGET_USD=0
+
+# Prompt templates, an optional directory of '<name>.v<version>.tmpl' files and pinned versions as name=v1
+export PROMPT_DIR=
+export PROMPT_VERSIONS=
+
+# Prompt/model experiment on a fraction of the products of an enrichment (reviewSentiments or relevantVideos)
+export EXPERIMENT_NAME=
+export EXPERIMENT_ENRICHMENT=reviewSentiments
+export EXPERIMENT_FRACTION=0.1
+export EXPERIMENT_PROMPT_VERSIONS=
+export EXPERIMENT_MODEL=
+
+# Metrics are served on /debug/vars, an empty address disables them
+export METRICS_ADDR=:9090
+export API_ADDR=:8080
+export API_ADMIN_TOKEN=
+export API_VOTE_TOKEN=
+export GRPC_ADDR=:9000
+export GRPC_TOKEN=
+export VIDEO_FEEDBACK_INTERVAL=1h
+export VIDEO_FEEDBACK_MIN_VOTES=5
+export VIDEO_FEEDBACK_DOWN_RATIO=0.6
+export VIDEO_FEEDBACK_MIN_VIDEOS=2
+export VIDEO_REFRESH_INTERVAL=24h
+export VIDEO_REFRESH_MAX_AGE=720h
+export VIDEO_REFRESH_BATCH_SIZE=50
+export CIRCUIT_BREAKER_WINDOW=1m
+export CIRCUIT_BREAKER_MIN_REQUESTS=10
+export CIRCUIT_BREAKER_FAILURE_RATIO=0.5
+export CIRCUIT_BREAKER_OPEN_TIMEOUT=30s
+export TRANSCRIPT_SOURCE=none
+export TRANSCRIPT_DIR=testdata/transcripts
+export HTTP_CASSETTE_MODE=none
+export HTTP_CASSETTE_DIR=testdata/cassettes
+export OPENAI_API_KEY=
+export OPENAI_MODEL=gpt-4o-mini
+export ANTHROPIC_API_KEY=
+export ANTHROPIC_MODEL=claude-3-haiku-20240307
+export OLLAMA_API_URL=http://localhost:11434
+export OLLAMA_MODEL=llama3
+
 # Firebase Configuration
 export FIREBASE_TYPE=service_account
 export FIREBASE_PROJECT_ID=
@@ -18,4 +78,24 @@ export FIREBASE_CLIENT_X509_CERT_URL=
 export FIREBASE_WRITE_TIMEOUT_SECOND=30s
 
 # Youtube Configuration
-export YOUTUBE_API_KEY=
\ No newline at end of file
+export YOUTUBE_API_KEY=
+export YOUTUBE_API_URL=
+export YOUTUBE_CAPTION_LANGUAGES=en
+export YOUTUBE_QUOTA_UNITS_PER_DAY=10000
+export YOUTUBE_SEARCH_CACHE_STORE=memory
+export YOUTUBE_SEARCH_CACHE_DIR=.cache/youtube
+export YOUTUBE_SEARCH_CACHE_TTL=24h
+export YOUTUBE_REGION_CODE=
+export YOUTUBE_RELEVANCE_LANGUAGE=
+export YOUTUBE_SAFE_SEARCH=moderate
+export YOUTUBE_PUBLISHED_WITHIN=
+export YOUTUBE_ALLOWED_CHANNELS=
+export YOUTUBE_DENIED_CHANNELS=
+
+# Video Sources Configuration
+export VIDEO_SOURCES=youtube
+export VIDEO_CATALOG_FILE=
+export VIMEO_ACCESS_TOKEN=
+export VIMEO_API_URL=https://api.vimeo.com
+export RELEVANT_VIDEOS_TOP_K=5
+export RELEVANT_VIDEOS_MAX_QUERIES=3
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"go-firestore-gpt/internal/cassette"
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/evaluation"
+	"go-firestore-gpt/internal/gpt"
+	"go-firestore-gpt/internal/gpt/provider"
+	gptutils "go-firestore-gpt/internal/gpt/utils"
+	relevantVideoHandler "go-firestore-gpt/internal/handler/relevantvideos"
+	reviewSentimentHandler "go-firestore-gpt/internal/handler/reviewsentiment"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/prompt"
+	"go-firestore-gpt/internal/transcript"
+	"go-firestore-gpt/internal/utils"
+)
+
+// Evaluates the enrichments against a directory of labeled products, e.g.
+//
+//	go run ./eval -dir ./eval/golden -target ollama/llama3
+//
+// Point the provider urls at a local endpoint, or replay a recorded cassette with HTTP_CASSETTE_MODE=replay, to run it offline.
+func main() {
+
+	dir := flag.String("dir", "./eval/golden", "directory of the labeled products")
+	enrichment := flag.String("enrichment", "", "evaluate only one enrichment, reviewSentiments or relevantVideos")
+	target := flag.String("target", "", "target as 'provider/model', defaults to the provider of each enrichment")
+	flag.Parse()
+
+	cnf := config.LoadOfflineConfigOrPanic()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cases, err := evaluation.LoadDataset(*dir)
+	if err != nil {
+		panic(err)
+	}
+
+	tokenizer, err := gptutils.NewTokenzier()
+	if err != nil {
+		panic(err)
+	}
+
+	prompts, err := prompt.New(cnf.Prompt)
+	if err != nil {
+		panic(err)
+	}
+
+	// no cache, so that every prompt change reaches the model
+	providers := provider.NewRegistry(cnf, cassette.NewHTTPClientOrPanic(cnf.HTTPCassette, "eval", cnf.LLM.RequestTimeout))
+
+	var analyzer evaluation.SentimentAnalyzer
+	if *enrichment == "" || *enrichment == model.EnrichmentReviewSentiments {
+		gptFactory := createGptFactoryOrPanic(providers, targetOr(*target, cnf.LLM.SentimentProvider))
+		analyzer = reviewSentimentHandler.New(nil, nil, nil, gptFactory, tokenizer, prompts, nil, nil, nil)
+	}
+
+	var evaluator evaluation.VideoEvaluator
+	if *enrichment == "" || *enrichment == model.EnrichmentRelevantVideos {
+		gptFactory := createGptFactoryOrPanic(providers, targetOr(*target, cnf.LLM.VideosProvider))
+		// only the files source is available, since there is no YouTube client
+		transcripts, err := transcript.New(cnf.Transcript, nil)
+		if err != nil {
+			panic(err)
+		}
+		evaluator = relevantVideoHandler.New(nil, nil, gptFactory, nil, transcripts, prompts, nil, nil, nil, cnf.RelevantVideos)
+	}
+
+	evaluation.Run(ctx, cases, analyzer, evaluator).Print(os.Stdout)
+}
+
+func targetOr(target, fallback string) string {
+	if target != "" {
+		return target
+	}
+	return fallback
+}
+
+func createGptFactoryOrPanic(providers *provider.Registry, target string) gpt.ClientFactory {
+	chain, err := gpt.ParseTargets([]string{target}, providers)
+	if err != nil {
+		panic(err)
+	}
+
+	// a zero temperature keeps the runs comparable
+	gptFactory, err := gpt.NewClientFactory(chain, nil, gpt.ClientConfig{
+		Temperature: utils.Float32ToPointer(0),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return gptFactory
+}
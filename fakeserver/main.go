@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+
+	"go-firestore-gpt/internal/fakeserver"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Serves a fake OpenAI compatible chat completions endpoint and YouTube Data API for integration testing, e.g.
+//
+//	go run ./fakeserver -addr :8089 -script ./fakeserver/script.json
+//
+// then run the backend with GILAS_API_URL=http://localhost:8089/v1 and YOUTUBE_API_URL=http://localhost:8089/
+func main() {
+
+	addr := flag.String("addr", ":8089", "address to listen on")
+	scriptPath := flag.String("script", "./fakeserver/script.json", "json file of the scripted responses")
+	flag.Parse()
+
+	data, err := os.ReadFile(*scriptPath)
+	if err != nil {
+		panic(err)
+	}
+
+	script := fakeserver.Script{}
+	if err := json.Unmarshal(data, &script); err != nil {
+		panic(err)
+	}
+
+	log.Info().Msgf("fake server listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, fakeserver.New(script)); err != nil {
+		panic(err)
+	}
+}
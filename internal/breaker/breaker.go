@@ -0,0 +1,224 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-firestore-gpt/internal/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type Config struct {
+	// the failure ratio is computed over the calls of a window
+	Window time.Duration
+	// calls of a window below which the breaker does not open
+	MinRequests int
+	// ratio of failed calls of a window which opens the breaker
+	FailureRatio float64
+	// time the breaker stays open before a probe call is let through
+	OpenTimeout time.Duration
+}
+
+var DefaultConfig = Config{
+	Window:       time.Minute,
+	MinRequests:  10,
+	FailureRatio: 0.5,
+	OpenTimeout:  time.Second * 30,
+}
+
+// Breaker stops calling a failing dependency. It opens once the failure ratio of a window is reached,
+// then lets a single probe call through after the open timeout, and closes again if the probe succeeds.
+type Breaker struct {
+	name      string
+	cnf       Config
+	isFailure func(error) bool
+	onChange  func(name string, state State)
+
+	mu          sync.Mutex
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+}
+
+// New creates a breaker whose failures are the transient errors, as classified by the retries.
+// Errors caused by the request itself, e.g. a 400 response, do not open the breaker.
+func New(name string, cnf Config) *Breaker {
+	return &Breaker{
+		name:        name,
+		cnf:         cnf,
+		isFailure:   isTransient,
+		windowStart: time.Now(),
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(time.Now())
+}
+
+// Do calls fn if the breaker allows it and records its outcome.
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	b.Record(err)
+	return err
+}
+
+// Allow returns an error wrapping ErrOpen if the call must not be made. The error is permanent,
+// so that the retries give up and the callers can fall back to another dependency.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState(time.Now()) {
+	case StateOpen:
+		return b.openError()
+	case StateHalfOpen:
+		if b.probing {
+			return b.openError()
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record records the outcome of an allowed call.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	failed := err != nil && b.isFailure(err)
+
+	switch b.currentState(now) {
+	case StateHalfOpen:
+		b.probing = false
+		if failed {
+			b.setState(StateOpen, now)
+		} else {
+			b.setState(StateClosed, now)
+		}
+
+	case StateClosed:
+		if now.Sub(b.windowStart) >= b.cnf.Window {
+			b.resetWindow(now)
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.cnf.MinRequests && float64(b.failures)/float64(b.requests) >= b.cnf.FailureRatio {
+			b.setState(StateOpen, now)
+		}
+	}
+}
+
+// Wait blocks while the breaker is open.
+func (b *Breaker) Wait(ctx context.Context) error {
+	for {
+		remaining := b.openRemaining()
+		if remaining <= 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// openRemaining returns the time left before an open breaker lets a probe through, zero if it is not open.
+func (b *Breaker) openRemaining() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.currentState(now) != StateOpen {
+		return 0
+	}
+
+	return b.openedAt.Add(b.cnf.OpenTimeout).Sub(now)
+}
+
+// currentState moves an open breaker to half-open once the open timeout elapsed. It must be called with the lock held.
+func (b *Breaker) currentState(now time.Time) State {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cnf.OpenTimeout && !b.probing {
+		b.setState(StateHalfOpen, now)
+	}
+	return b.state
+}
+
+// setState must be called with the lock held.
+func (b *Breaker) setState(state State, now time.Time) {
+	if b.state == state {
+		return
+	}
+
+	b.state = state
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+		log.Error().Msgf("circuit breaker %s opened", b.name)
+	case StateClosed:
+		b.resetWindow(now)
+		log.Info().Msgf("circuit breaker %s closed", b.name)
+	}
+
+	if b.onChange != nil {
+		b.onChange(b.name, state)
+	}
+}
+
+func (b *Breaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
+
+func (b *Breaker) openError() error {
+	return utils.Permanent(fmt.Errorf("%w: %s", ErrOpen, b.name))
+}
+
+func isTransient(err error) bool {
+	retryable, _ := utils.Classify(err)
+	return retryable
+}
@@ -0,0 +1,133 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-firestore-gpt/internal/utils"
+)
+
+var errTransient = errors.New("connection reset")
+
+var testConfig = Config{
+	Window:       time.Minute,
+	MinRequests:  4,
+	FailureRatio: 0.5,
+	OpenTimeout:  time.Millisecond * 50,
+}
+
+func record(b *Breaker, errs ...error) {
+	for _, err := range errs {
+		b.Record(err)
+	}
+}
+
+func TestBreakerOpens(t *testing.T) {
+	tests := []struct {
+		name      string
+		outcomes  []error
+		wantState State
+	}{
+		{"below the min requests", []error{errTransient, errTransient, errTransient}, StateClosed},
+		{"below the failure ratio", []error{nil, nil, errTransient, nil}, StateClosed},
+		{"at the failure ratio", []error{nil, errTransient, nil, errTransient}, StateOpen},
+		{"all failed", []error{errTransient, errTransient, errTransient, errTransient}, StateOpen},
+		{"permanent errors are not failures", []error{utils.Permanent(errTransient), utils.Permanent(errTransient), errTransient, nil}, StateClosed},
+		{"canceled calls are not failures", []error{context.Canceled, context.Canceled, context.Canceled, context.Canceled}, StateClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.name, testConfig)
+			record(b, tt.outcomes...)
+			if got := b.State(); got != tt.wantState {
+				t.Errorf("got %s, want %s", got, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestBreakerWindow(t *testing.T) {
+	cnf := testConfig
+	cnf.Window = time.Millisecond * 20
+	b := New("window", cnf)
+
+	// the failures of a past window do not count
+	record(b, errTransient, errTransient, errTransient)
+	time.Sleep(cnf.Window)
+	record(b, nil, nil, nil, errTransient)
+	if got := b.State(); got != StateClosed {
+		t.Errorf("got %s, want %s", got, StateClosed)
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	b := New("transitions", testConfig)
+	record(b, errTransient, errTransient, errTransient, errTransient)
+
+	// an open breaker rejects the calls with a permanent error, so the retries give up
+	calls := 0
+	err := b.Do(func() error { calls++; return nil })
+	if !errors.Is(err, ErrOpen) || !utils.IsPermanent(err) || calls != 0 {
+		t.Fatalf("open: got %v after %d calls, want a permanent %v", err, calls, ErrOpen)
+	}
+
+	time.Sleep(testConfig.OpenTimeout)
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("got %s, want %s once the open timeout elapsed", got, StateHalfOpen)
+	}
+
+	// a single probe is let through
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe: got %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("second probe: got %v, want %v", err, ErrOpen)
+	}
+
+	// a failed probe opens the breaker again
+	b.Record(errTransient)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("got %s, want %s after a failed probe", got, StateOpen)
+	}
+
+	// a successful probe closes it
+	time.Sleep(testConfig.OpenTimeout)
+	if err := b.Do(func() error { return nil }); err != nil {
+		t.Fatalf("probe: got %v", err)
+	}
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("got %s, want %s after a successful probe", got, StateClosed)
+	}
+
+	// the closed breaker starts a new window
+	record(b, errTransient, errTransient, errTransient)
+	if got := b.State(); got != StateClosed {
+		t.Errorf("got %s, want %s below the min requests of the new window", got, StateClosed)
+	}
+}
+
+func TestBreakerWait(t *testing.T) {
+	b := New("wait", testConfig)
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("closed: got %v", err)
+	}
+
+	record(b, errTransient, errTransient, errTransient, errTransient)
+	start := time.Now()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited < testConfig.OpenTimeout/2 {
+		t.Errorf("waited %s, want about the open timeout %s", waited, testConfig.OpenTimeout)
+	}
+
+	record(b, errTransient)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
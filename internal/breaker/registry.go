@@ -0,0 +1,157 @@
+package breaker
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/metrics"
+)
+
+// names of the breakers of the shared dependencies
+const (
+	Database = "database"
+	// the database listeners share a breaker of their own, see GetWithConfig
+	DatabaseListener = "database/listener"
+	YouTube          = "youtube"
+	Vimeo            = "vimeo"
+)
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Breaker{}
+	defaultCnf = DefaultConfig
+)
+
+// Configure sets the config of the breakers created afterwards. It must be called before any breaker is used.
+func Configure(cnf config.CircuitBreaker) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	defaultCnf = Config{
+		Window:       cnf.Window,
+		MinRequests:  cnf.MinRequests,
+		FailureRatio: cnf.FailureRatio,
+		OpenTimeout:  cnf.OpenTimeout,
+	}
+}
+
+// Get returns the shared breaker of the dependency, creating it on the first call.
+// Its state is published in the circuit_breakers metric.
+func Get(name string) *Breaker {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	return get(name, defaultCnf)
+}
+
+// GetWithConfig is Get for a dependency which does not use the configured thresholds. The config is only used
+// by the call which creates the breaker.
+func GetWithConfig(name string, cnf Config) *Breaker {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	return get(name, cnf)
+}
+
+// get must be called with the lock held.
+func get(name string, cnf Config) *Breaker {
+	if b, ok := registry[name]; ok {
+		return b
+	}
+
+	state := new(expvar.String)
+	state.Set(StateClosed.String())
+	metrics.CircuitBreakers.Set(name, state)
+
+	b := New(name, cnf)
+	b.onChange = func(name string, s State) {
+		state.Set(s.String())
+		if s == StateOpen {
+			metrics.CircuitBreakerOpens.Add(name, 1)
+		}
+	}
+	registry[name] = b
+	return b
+}
+
+// LLM returns the breaker of the llm provider.
+func LLM(provider string) *Breaker {
+	return Get("llm/" + provider)
+}
+
+// Check is a health check which fails while any of the shared breakers is open.
+func Check() error {
+	registryMu.Lock()
+	breakers := make([]*Breaker, 0, len(registry))
+	for _, b := range registry {
+		breakers = append(breakers, b)
+	}
+	registryMu.Unlock()
+
+	open := []string{}
+	for _, b := range breakers {
+		if b.State() == StateOpen {
+			open = append(open, b.Name())
+		}
+	}
+	if len(open) == 0 {
+		return nil
+	}
+
+	sort.Strings(open)
+	return fmt.Errorf("open circuit breakers: %s", strings.Join(open, ", "))
+}
+
+// Gate pauses an enrichment while its dependencies are down. Each group lists interchangeable dependencies,
+// e.g. the providers of a fallback chain, and the gate is open once every group has a dependency which is not open.
+type Gate struct {
+	groups [][]*Breaker
+}
+
+func NewGate(groups ...[]*Breaker) *Gate {
+	return &Gate{groups: groups}
+}
+
+// Wait blocks until the gate is open. A nil gate never blocks.
+func (g *Gate) Wait(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+
+	for {
+		remaining := g.closedRemaining()
+		if remaining <= 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// closedRemaining returns the time before every group may have a dependency to call, zero if they already have.
+func (g *Gate) closedRemaining() time.Duration {
+	var longest time.Duration
+	for _, group := range g.groups {
+		shortest := time.Duration(-1)
+		for _, b := range group {
+			remaining := b.openRemaining()
+			if shortest < 0 || remaining < shortest {
+				shortest = remaining
+			}
+		}
+		if shortest > longest {
+			longest = shortest
+		}
+	}
+	return longest
+}
@@ -0,0 +1,99 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unregister removes the breakers of the test from the registry once it finished, so it can run again
+func unregister(t *testing.T, names ...string) {
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		for _, name := range names {
+			delete(registry, name)
+		}
+	})
+}
+
+func TestGet(t *testing.T) {
+	unregister(t, "registry/shared", "registry/custom")
+
+	b := Get("registry/shared")
+	if Get("registry/shared") != b {
+		t.Error("got another breaker, want the shared one")
+	}
+
+	// the config is only used by the call which creates the breaker
+	cnf := testConfig
+	cnf.MinRequests = 1
+	custom := GetWithConfig("registry/custom", cnf)
+	if GetWithConfig("registry/custom", DefaultConfig) != custom || custom.cnf != cnf {
+		t.Errorf("got config %+v, want %+v", custom.cnf, cnf)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cnf := testConfig
+	cnf.OpenTimeout = time.Minute
+	unregister(t, "registry/check-a", "registry/check-b")
+
+	a := GetWithConfig("registry/check-b", cnf)
+	b := GetWithConfig("registry/check-a", cnf)
+	if err := Check(); err != nil {
+		t.Fatalf("got %v, want the closed breakers healthy", err)
+	}
+
+	record(a, errTransient, errTransient, errTransient, errTransient)
+	record(b, errTransient, errTransient, errTransient, errTransient)
+	err := Check()
+	if err == nil || !strings.Contains(err.Error(), "registry/check-a, registry/check-b") {
+		t.Errorf("got %v, want the open breakers sorted", err)
+	}
+}
+
+func TestGate(t *testing.T) {
+	open := func(name string, timeout time.Duration) *Breaker {
+		cnf := testConfig
+		cnf.OpenTimeout = timeout
+		b := New(name, cnf)
+		record(b, errTransient, errTransient, errTransient, errTransient)
+		return b
+	}
+	closed := New("closed", testConfig)
+
+	tests := []struct {
+		name     string
+		gate     *Gate
+		wantWait bool
+	}{
+		{"nil gate", nil, false},
+		{"closed dependencies", NewGate([]*Breaker{closed}), false},
+		{"a fallback is closed", NewGate([]*Breaker{open("primary", time.Minute), closed}), false},
+		{"every fallback is open", NewGate([]*Breaker{open("primary", time.Minute), open("fallback", time.Minute)}), true},
+		{"a group is open", NewGate([]*Breaker{closed}, []*Breaker{open("database", time.Minute)}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+			defer cancel()
+
+			err := tt.gate.Wait(ctx)
+			if waited := errors.Is(err, context.DeadlineExceeded); waited != tt.wantWait {
+				t.Errorf("got %v, want waiting %v", err, tt.wantWait)
+			}
+		})
+	}
+
+	// the gate opens once a breaker of every group lets a probe through
+	gate := NewGate([]*Breaker{open("short", time.Millisecond*20), open("long", time.Minute)})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := gate.Wait(ctx); err != nil {
+		t.Errorf("got %v, want the gate open after the shortest open timeout", err)
+	}
+}
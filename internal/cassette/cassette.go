@@ -0,0 +1,244 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/utils"
+)
+
+type Mode string
+
+const (
+	// ModeNone disables the cassette
+	ModeNone Mode = "none"
+	// ModeRecord sends the requests and appends the interactions to the cassette
+	ModeRecord Mode = "record"
+	// ModeReplay answers the requests from the cassette without network
+	ModeReplay Mode = "replay"
+)
+
+var ErrNoInteraction = errors.New("cassette: no recorded interaction matches the request")
+
+// query parameters which carry credentials, they are neither recorded nor matched
+var redactedParams = []string{"key", "api_key"}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Transport is an http.RoundTripper which records the interactions into a cassette file or replays them.
+// Requests are matched by their method, url and body. Identical requests are replayed in the recorded order,
+// and the last matching interaction is repeated once they are exhausted, e.g. for retries.
+type Transport struct {
+	path string
+	mode Mode
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[string]int
+}
+
+// New loads the cassette of the path. The next round tripper sends the requests in the record mode,
+// http.DefaultTransport is used if it is nil.
+func New(path string, mode Mode, next http.RoundTripper) (*Transport, error) {
+	if mode != ModeRecord && mode != ModeReplay {
+		return nil, fmt.Errorf("cassette: unknown mode '%s'", mode)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &Transport{path: path, mode: mode, next: next, replayed: make(map[string]int)}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &t.interactions); err != nil {
+			return nil, fmt.Errorf("cassette: load %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && mode == ModeRecord:
+		// the cassette is created by the first recorded interaction
+	default:
+		return nil, fmt.Errorf("cassette: load %s: %w", path, err)
+	}
+
+	return t, nil
+}
+
+// NewHTTPClientOrPanic returns an http client recording or replaying the interactions of the named service into
+// its cassette of the directory, e.g. 'openai.json'. It returns nil if the cassettes are disabled, i.e. when the mode
+// is empty or none, so that the callers fall back to their default client.
+func NewHTTPClientOrPanic(cnf config.HTTPCassette, name string, timeout time.Duration) *http.Client {
+	mode := Mode(cnf.Mode)
+	if mode == "" || mode == ModeNone {
+		return nil
+	}
+
+	transport, err := New(filepath.Join(cnf.Dir, name+".json"), mode, nil)
+	if err != nil {
+		panic(err)
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// Client returns an http client using the transport.
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.mode == ModeReplay {
+		return t.replay(req, recorded)
+	}
+	return t.record(req, recorded)
+}
+
+func (t *Transport) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := recorded.key()
+	skip := t.replayed[key]
+
+	var match *Interaction
+	for i := range t.interactions {
+		if t.interactions[i].Request.key() != key {
+			continue
+		}
+		match = &t.interactions[i]
+		if skip == 0 {
+			break
+		}
+		skip--
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+	}
+	t.replayed[key]++
+
+	return match.Response.toHTTP(req), nil
+}
+
+func (t *Transport) record(req *http.Request, recorded Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+
+	interaction := Interaction{
+		Request:  recorded,
+		Response: Response{StatusCode: resp.StatusCode, Header: header, Body: string(body)},
+	}
+
+	t.mu.Lock()
+	t.interactions = append(t.interactions, interaction)
+	err = t.save()
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// save writes the whole cassette, so that nothing is lost if the process is killed. It must be called with the lock held.
+func (t *Transport) save() error {
+	data, err := json.MarshalIndent(t.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return fmt.Errorf("cassette: save %s: %w", t.path, err)
+	}
+	if err := os.WriteFile(t.path, data, 0o644); err != nil {
+		return fmt.Errorf("cassette: save %s: %w", t.path, err)
+	}
+	return nil
+}
+
+func newRequest(req *http.Request) (Request, error) {
+	recorded := Request{Method: req.Method, URL: redactURL(req.URL)}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return recorded, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		recorded.Body = string(body)
+	}
+
+	return recorded, nil
+}
+
+func (r Request) key() string {
+	return r.Method + " " + r.URL + " " + utils.Hash(r.Body)
+}
+
+func (r Response) toHTTP(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(r.Body))),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for _, param := range redactedParams {
+		query.Del(param)
+	}
+	// Encode sorts the parameters, so the order of the query does not matter when matching
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
@@ -0,0 +1,120 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-firestore-gpt/internal/cassette"
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/gpt/provider"
+	"go-firestore-gpt/internal/youtube"
+)
+
+// The cassettes of testdata were recorded against the fixed hosts of the configs below.
+
+var llmConfig = config.Config{
+	OpenAI:    config.OpenAI{ApiKey: "test", ApiUrl: "https://openai.test/v1", Model: "gpt-4o-mini"},
+	Anthropic: config.Anthropic{ApiKey: "test", ApiUrl: "https://anthropic.test/v1", Model: "claude-3-haiku-20240307", Version: "2023-06-01", MaxTokens: 1024},
+	Ollama:    config.Ollama{ApiUrl: "http://ollama.test", Model: "llama3"},
+}
+
+var productNameRequest = provider.Request{Messages: []provider.Message{
+	{Role: provider.RoleSystem, Content: "Extract the name of the product."},
+	{Role: provider.RoleUser, Content: "Amazon Kindle Paperwhite 8 GB, waterproof, 6.8 inch display"},
+}}
+
+func replay(t *testing.T, path string) *cassette.Transport {
+	t.Helper()
+
+	transport, err := cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("load cassette: %v", err)
+	}
+	return transport
+}
+
+func TestReplayProviders(t *testing.T) {
+	tests := []struct {
+		provider         string
+		model            string
+		promptTokens     int
+		completionTokens int
+	}{
+		{provider.OpenAI, "gpt-4o-mini", 21, 3},
+		{provider.Anthropic, "claude-3-haiku-20240307", 24, 4},
+		{provider.Ollama, "llama3", 26, 5},
+	}
+
+	transport := replay(t, "testdata/llm.json")
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			p, err := provider.New(tt.provider, llmConfig, transport.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := p.Complete(context.Background(), productNameRequest)
+			if err != nil {
+				t.Fatalf("complete: %v", err)
+			}
+			if resp.Content != "Kindle Paperwhite" || resp.Model != tt.model {
+				t.Errorf("got %q of model %q, want %q of model %q", resp.Content, resp.Model, "Kindle Paperwhite", tt.model)
+			}
+			if resp.PromptTokens != tt.promptTokens || resp.CompletionTokens != tt.completionTokens {
+				t.Errorf("got %d/%d tokens, want %d/%d", resp.PromptTokens, resp.CompletionTokens, tt.promptTokens, tt.completionTokens)
+			}
+		})
+	}
+}
+
+func TestReplayUnrecordedRequest(t *testing.T) {
+	p, err := provider.New(provider.OpenAI, llmConfig, replay(t, "testdata/llm.json").Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := provider.Request{Messages: []provider.Message{{Role: provider.RoleUser, Content: "not recorded"}}}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("got error %v, want %v", err, cassette.ErrNoInteraction)
+	}
+}
+
+// The YouTube client is a singleton, so it is only created by this test.
+func TestReplayYouTube(t *testing.T) {
+	cnf := config.Youtube{ApiKey: "secret", ApiUrl: "https://youtube.test/", SafeSearch: "moderate"}
+	client := youtube.NewYouTubeClient(context.Background(), cnf, replay(t, "testdata/youtube.json").Client(), nil, nil)
+	if client == nil {
+		t.Fatal("no youtube client")
+	}
+
+	videos, err := client.Search(context.Background(), "Kindle Paperwhite review", 5, youtube.SearchOptions{RegionCode: "US"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(videos) != 2 || videos[0].ID != "dQw4w9WgXcQ" || videos[1].URL != "https://www.youtube.com/watch?v=9bZkp7q19f0" {
+		t.Fatalf("unexpected videos %+v", videos)
+	}
+
+	detailed, err := client.Details(context.Background(), videos)
+	if err != nil {
+		t.Fatalf("details: %v", err)
+	}
+	details := detailed[0].Details
+	if details == nil || details.ChannelTitle != "Tech Reviews" || details.ViewCount != 1200 || details.Duration.String() != "8m2s" {
+		t.Errorf("unexpected details %+v", details)
+	}
+}
+
+func TestNewHTTPClientOrPanic(t *testing.T) {
+	for _, mode := range []string{"", string(cassette.ModeNone)} {
+		if client := cassette.NewHTTPClientOrPanic(config.HTTPCassette{Mode: mode}, "llm", 0); client != nil {
+			t.Errorf("mode %q: got a client, want the cassettes disabled", mode)
+		}
+	}
+
+	client := cassette.NewHTTPClientOrPanic(config.HTTPCassette{Mode: string(cassette.ModeReplay), Dir: "testdata"}, "llm", 0)
+	if _, ok := client.Transport.(*cassette.Transport); !ok {
+		t.Errorf("got transport %T, want a cassette", client.Transport)
+	}
+}
@@ -14,6 +14,143 @@ type GilasAI struct {
 	Model  string `env:"GILAS_GPT_MODEL" envDefault:"gpt-3.5-turbo"`
 }
 
+type OpenAI struct {
+	ApiKey string `env:"OPENAI_API_KEY"`
+	ApiUrl string `env:"OPENAI_API_URL" envDefault:"https://api.openai.com/v1"`
+	Model  string `env:"OPENAI_MODEL" envDefault:"gpt-4o-mini"`
+}
+
+type Anthropic struct {
+	ApiKey    string `env:"ANTHROPIC_API_KEY"`
+	ApiUrl    string `env:"ANTHROPIC_API_URL" envDefault:"https://api.anthropic.com/v1"`
+	Model     string `env:"ANTHROPIC_MODEL" envDefault:"claude-3-haiku-20240307"`
+	Version   string `env:"ANTHROPIC_VERSION" envDefault:"2023-06-01"`
+	MaxTokens int    `env:"ANTHROPIC_MAX_TOKENS" envDefault:"4096"`
+}
+
+type Ollama struct {
+	ApiUrl string `env:"OLLAMA_API_URL" envDefault:"http://localhost:11434"`
+	Model  string `env:"OLLAMA_MODEL" envDefault:"llama3"`
+}
+
+// LLM selects the provider of each enrichment, one of gilas, openai, anthropic or ollama.
+// Targets are formatted as 'provider/model' or 'provider' for the default model of the provider.
+type LLM struct {
+	SentimentProvider string        `env:"LLM_SENTIMENT_PROVIDER" envDefault:"gilas"`
+	VideosProvider    string        `env:"LLM_VIDEOS_PROVIDER" envDefault:"gilas"`
+	RequestTimeout    time.Duration `env:"LLM_REQUEST_TIMEOUT" envDefault:"2m"`
+	// e.g. sentiment-analysis=openai/gpt-4o|gilas/gpt-4o,product-name-extraction=gilas/gpt-3.5-turbo
+	TaskModels []string `env:"LLM_TASK_MODELS"`
+	// targets tried in order after the provider of the enrichment fails, e.g. openai/gpt-4o-mini,ollama
+	FallbackChain []string `env:"LLM_FALLBACK_CHAIN"`
+	// limits of each provider, zero disables the limit
+	RequestsPerMinute int `env:"LLM_REQUESTS_PER_MINUTE"`
+	TokensPerMinute   int `env:"LLM_TOKENS_PER_MINUTE"`
+}
+
+// LLMCache selects the store of the llm response cache, one of none, memory, disk or database
+type LLMCache struct {
+	Store string        `env:"LLM_CACHE_STORE" envDefault:"none"`
+	Dir   string        `env:"LLM_CACHE_DIR" envDefault:".cache/llm"`
+	TTL   time.Duration `env:"LLM_CACHE_TTL" envDefault:"168h"`
+}
+
+// LLMUsage sets the prices as 'model=prompt/completion' in USD per one million tokens,
+// and the monthly budget in USD after which the enrichments are paused. A zero budget disables it.
+type LLMUsage struct {
+	Prices        []string `env:"LLM_PRICES"`
+	MonthlyBudget float64  `env:"LLM_MONTHLY_BUDGET_USD"`
+}
+
+// Prompt sets an external directory of prompt templates and pins prompt versions as 'name=v1'
+type Prompt struct {
+	Dir      string   `env:"PROMPT_DIR"`
+	Versions []string `env:"PROMPT_VERSIONS"`
+}
+
+// Experiment processes a fraction of the products of an enrichment (reviewSentiments or relevantVideos)
+// with alternative prompt versions, formatted as 'name=v2', and/or an alternative 'provider/model' target.
+// An empty name disables the experiment.
+type Experiment struct {
+	Name           string   `env:"EXPERIMENT_NAME"`
+	Enrichment     string   `env:"EXPERIMENT_ENRICHMENT"`
+	Fraction       float64  `env:"EXPERIMENT_FRACTION" envDefault:"0.1"`
+	PromptVersions []string `env:"EXPERIMENT_PROMPT_VERSIONS"`
+	Model          string   `env:"EXPERIMENT_MODEL"`
+}
+
+type Metrics struct {
+	// An empty address disables the metrics server
+	Addr string `env:"METRICS_ADDR" envDefault:":9090"`
+}
+
+// CircuitBreaker opens the breaker of a dependency, e.g. an llm provider, YouTube or the database, once the ratio
+// of failed calls of a window is reached with at least the minimum number of calls.
+type CircuitBreaker struct {
+	Window       time.Duration `env:"CIRCUIT_BREAKER_WINDOW" envDefault:"1m"`
+	MinRequests  int           `env:"CIRCUIT_BREAKER_MIN_REQUESTS" envDefault:"10"`
+	FailureRatio float64       `env:"CIRCUIT_BREAKER_FAILURE_RATIO" envDefault:"0.5"`
+	OpenTimeout  time.Duration `env:"CIRCUIT_BREAKER_OPEN_TIMEOUT" envDefault:"30s"`
+}
+
+// RelevantVideos keeps the TopK best scored videos of a product, zero keeps all of them.
+// The videos are searched with up to MaxQueries query variants of the product name.
+type RelevantVideos struct {
+	TopK       int `env:"RELEVANT_VIDEOS_TOP_K" envDefault:"5"`
+	MaxQueries int `env:"RELEVANT_VIDEOS_MAX_QUERIES" envDefault:"3"`
+}
+
+// VideoFeedback periodically demotes the videos with at least MinVotes votes of which DownRatio or more are
+// thumbs down. A product is searched again once a demotion leaves it with fewer than MinVideos videos.
+// A zero interval disables the job.
+type VideoFeedback struct {
+	Interval  time.Duration `env:"VIDEO_FEEDBACK_INTERVAL" envDefault:"1h"`
+	MinVotes  int           `env:"VIDEO_FEEDBACK_MIN_VOTES" envDefault:"5"`
+	DownRatio float64       `env:"VIDEO_FEEDBACK_DOWN_RATIO" envDefault:"0.6"`
+	MinVideos int           `env:"VIDEO_FEEDBACK_MIN_VIDEOS" envDefault:"2"`
+}
+
+// VideoRefresh checks the videos of the products which were not checked for an interval, at most BatchSize
+// products an hour. The videos of a product are searched again if any of them is not available anymore,
+// or if they were searched more than MaxAge ago. A zero interval disables the job.
+type VideoRefresh struct {
+	Interval  time.Duration `env:"VIDEO_REFRESH_INTERVAL" envDefault:"24h"`
+	MaxAge    time.Duration `env:"VIDEO_REFRESH_MAX_AGE" envDefault:"720h"`
+	BatchSize int           `env:"VIDEO_REFRESH_BATCH_SIZE" envDefault:"50"`
+}
+
+// API serves the http api on the address, e.g. ':8080'. An empty address disables it.
+// The admin endpoints are only served when the AdminToken is set, which their requests send as a bearer token.
+// Likewise the votes on the videos are only counted when the VoteToken is set, e.g. sent by the backend of the shop
+// once it identified the user, so the videos can not be demoted by anonymous votes.
+type API struct {
+	Addr       string `env:"API_ADDR"`
+	AdminToken string `env:"API_ADMIN_TOKEN"`
+	VoteToken  string `env:"API_VOTE_TOKEN"`
+}
+
+// GRPC serves the grpc service on the address, e.g. ':9000'. An empty address disables it.
+// CreateProduct and AddReviews are served only when the Token is set, their calls send it as a bearer token.
+type GRPC struct {
+	Addr  string `env:"GRPC_ADDR"`
+	Token string `env:"GRPC_TOKEN"`
+}
+
+// Transcript selects the source of the video transcripts used by the video evaluation, one of none, captions or files.
+// The captions source downloads the YouTube caption tracks, which requires OAuth credentials allowed to download them.
+// The files source reads '<videoId>.vtt', '.srt' or '.txt' files of the directory.
+type Transcript struct {
+	Source string `env:"TRANSCRIPT_SOURCE" envDefault:"none"`
+	Dir    string `env:"TRANSCRIPT_DIR" envDefault:"testdata/transcripts"`
+}
+
+// HTTPCassette records the llm and youtube http interactions into cassette files of the directory,
+// or replays them without network. The mode is one of none, record or replay, an empty mode is none.
+type HTTPCassette struct {
+	Mode string `env:"HTTP_CASSETTE_MODE" envDefault:"none"`
+	Dir  string `env:"HTTP_CASSETTE_DIR" envDefault:"testdata/cassettes"`
+}
+
 type Firebase struct {
 	Type                    string        `env:"FIREBASE_TYPE,required" json:"type"`
 	ProjectId               string        `env:"FIREBASE_PROJECT_ID,required" json:"project_id"`
@@ -30,12 +167,63 @@ type Firebase struct {
 
 type Youtube struct {
 	ApiKey string `env:"YOUTUBE_API_KEY"`
+	// overrides the endpoint of the YouTube Data API, e.g. to point at a fake server
+	ApiUrl string `env:"YOUTUBE_API_URL"`
+	// preferred languages of the caption tracks, in order
+	CaptionLanguages []string `env:"YOUTUBE_CAPTION_LANGUAGES" envDefault:"en"`
+	// hard cap of the quota units spent per day by all the workers, zero disables the limit
+	QuotaUnitsPerDay int `env:"YOUTUBE_QUOTA_UNITS_PER_DAY" envDefault:"10000"`
+	// store of the search results cache, one of none, memory, disk or database
+	SearchCacheStore string        `env:"YOUTUBE_SEARCH_CACHE_STORE" envDefault:"memory"`
+	SearchCacheDir   string        `env:"YOUTUBE_SEARCH_CACHE_DIR" envDefault:".cache/youtube"`
+	SearchCacheTTL   time.Duration `env:"YOUTUBE_SEARCH_CACHE_TTL" envDefault:"24h"`
+	// defaults of the search, the region and the language of a product override them
+	RegionCode        string `env:"YOUTUBE_REGION_CODE"`
+	RelevanceLanguage string `env:"YOUTUBE_RELEVANCE_LANGUAGE"`
+	// one of none, moderate or strict
+	SafeSearch string `env:"YOUTUBE_SAFE_SEARCH" envDefault:"moderate"`
+	// only the videos published within the window are searched, zero searches all of them
+	PublishedWithin time.Duration `env:"YOUTUBE_PUBLISHED_WITHIN"`
+	// channel ids whose videos are kept, an empty list keeps every channel but the denied ones
+	AllowedChannels []string `env:"YOUTUBE_ALLOWED_CHANNELS"`
+	DeniedChannels  []string `env:"YOUTUBE_DENIED_CHANNELS"`
+}
+
+type Vimeo struct {
+	AccessToken string `env:"VIMEO_ACCESS_TOKEN"`
+	ApiUrl      string `env:"VIMEO_API_URL" envDefault:"https://api.vimeo.com"`
+}
+
+// VideoSource lists the sources of the candidate videos, any of youtube, vimeo or catalog.
+// Their results are merged in the listed order. The catalog is a JSON file of curated videos.
+type VideoSource struct {
+	Sources     []string `env:"VIDEO_SOURCES" envDefault:"youtube"`
+	CatalogFile string   `env:"VIDEO_CATALOG_FILE"`
 }
 
 type Config struct {
 	GilasAI
+	OpenAI
+	Anthropic
+	Ollama
+	LLM
+	LLMCache
+	LLMUsage
+	Prompt
+	Experiment
+	Metrics
+	CircuitBreaker
+	Transcript
+	HTTPCassette
 	Firebase
 	Youtube
+	Vimeo
+	VideoSource
+	RelevantVideos
+	VideoFeedback
+	VideoRefresh
+	API
+	GRPC
 }
 
 func LoadConfigOrPanic() Config {
@@ -48,6 +236,25 @@ func LoadConfigOrPanic() Config {
 	return *config
 }
 
+// LoadOfflineConfigOrPanic loads the config of the commands which run without the database, e.g. the evaluations.
+// The firebase credentials are not loaded.
+func LoadOfflineConfigOrPanic() Config {
+	var config *Config = new(Config)
+	for _, c := range []interface{}{
+		&config.GilasAI, &config.OpenAI, &config.Anthropic, &config.Ollama,
+		&config.LLM, &config.LLMCache, &config.LLMUsage, &config.Prompt,
+		&config.Experiment, &config.Metrics, &config.CircuitBreaker, &config.Transcript, &config.HTTPCassette, &config.Youtube,
+		&config.Vimeo, &config.VideoSource, &config.RelevantVideos,
+		&config.VideoFeedback, &config.VideoRefresh, &config.API, &config.GRPC,
+	} {
+		if err := env.Parse(c); err != nil {
+			panic(err)
+		}
+	}
+
+	return *config
+}
+
 func (c *Config) normalize() {
 
 	decodedBytes, err := base64.StdEncoding.DecodeString(c.Firebase.PrivateKey)
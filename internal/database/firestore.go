@@ -2,10 +2,13 @@ package database
 
 import (
 	"context"
-	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"go-firestore-gpt/internal/breaker"
+	"go-firestore-gpt/internal/utils"
+
 	"cloud.google.com/go/firestore"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/api/iterator"
@@ -18,6 +21,14 @@ type snapEvent struct {
 
 type snapCh chan snapEvent
 
+// the listeners stop once half of their snapshots of a minute fail, with at least 20 snapshots
+var listenerBreakerConfig = breaker.Config{
+	Window:       time.Minute,
+	MinRequests:  20,
+	FailureRatio: 0.5,
+	OpenTimeout:  time.Minute,
+}
+
 type FirestoreClient struct {
 	*firestore.Client
 	writeTimeout time.Duration
@@ -31,13 +42,12 @@ func New(client *firestore.Client) FirestoreClient {
 }
 
 // This function listens to the given SnapshotIterator and put all the events on the ChangeEvent channel.
-// The cicuite breaker pattern here defines a error rate tolarance cap. If the listener raises error more than
-// the given cap, it stops the listener and closes the ChangeEvent channel.
-func (c FirestoreClient) NotifyOnChanges(ctx context.Context, it *firestore.QuerySnapshotIterator, kind firestore.DocumentChangeKind) <-chan ChangeEvent {
+// A circuit breaker shared by the listeners tracks their error rate. Once it opens, a failing listener stops and
+// closes the ChangeEvent channel.
+func (c FirestoreClient) NotifyOnChanges(ctx context.Context, it *firestore.QuerySnapshotIterator, kinds ...firestore.DocumentChangeKind) <-chan ChangeEvent {
 
 	ch := make(chan ChangeEvent)
-	errToleranceCap := 20
-	errCnt := 0
+	listenerBreaker := breaker.GetWithConfig(breaker.DatabaseListener, listenerBreakerConfig)
 
 	go func() {
 		defer close(ch)
@@ -51,16 +61,17 @@ func (c FirestoreClient) NotifyOnChanges(ctx context.Context, it *firestore.Quer
 				}
 
 				log.Error().Err(event.err).Msg("error reading events")
-				errCnt++
-				if errCnt < errToleranceCap {
+				listenerBreaker.Record(event.err)
+				if listenerBreaker.State() != breaker.StateOpen {
 					continue
 				}
 				ch <- ChangeEvent{Err: event.err}
 				return
 			}
+			listenerBreaker.Record(nil)
 
 			for _, change := range event.snap.Changes {
-				if change.Kind == kind {
+				if slices.Contains(kinds, change.Kind) {
 					if change.Doc == nil {
 						continue
 					}
@@ -135,52 +146,101 @@ func (c FirestoreClient) IterDocs(ctx context.Context, coll *firestore.Collectio
 	}
 }
 
-func (c FirestoreClient) GetDoc(ctx context.Context, docRef *firestore.DocumentRef) (*firestore.DocumentSnapshot, error) {
+// GetDoc reads the doc through the database circuit breaker. A missing doc fails with codes.NotFound.
+func (c FirestoreClient) GetDoc(ctx context.Context, docRef *firestore.DocumentRef) (docSnapshot *firestore.DocumentSnapshot, err error) {
 	ctx, cancel := context.WithTimeout(ctx, c.writeTimeout)
 	defer cancel()
 
-	docSnapshot, err := docRef.Get(ctx)
-	if err != nil {
-		return nil, err
-	}
+	err = breaker.Get(breaker.Database).Do(func() error {
+		docSnapshot, err = docRef.Get(ctx)
+		return err
+	})
+	return docSnapshot, err
+}
 
-	if !docSnapshot.Exists() {
-		return nil, fmt.Errorf("doc snapshot does not exist")
-	}
+// GetDocs reads all the docs of the query through the database circuit breaker.
+func (c FirestoreClient) GetDocs(ctx context.Context, query firestore.Query) (docs []*firestore.DocumentSnapshot, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.writeTimeout)
+	defer cancel()
 
-	return docSnapshot, nil
+	err = breaker.Get(breaker.Database).Do(func() error {
+		docs, err = query.Documents(ctx).GetAll()
+		return err
+	})
+	return docs, err
 }
 
-func (c FirestoreClient) UpdateDoc(ctx context.Context, docRef *firestore.DocumentRef, updates []firestore.Update, preconds ...firestore.Precondition) (_ *firestore.WriteResult, err error) {
+func (c FirestoreClient) UpdateDoc(ctx context.Context, docRef *firestore.DocumentRef, updates []firestore.Update, preconds ...firestore.Precondition) (result *firestore.WriteResult, err error) {
 	ctx, cancel := context.WithTimeout(ctx, c.writeTimeout)
 	defer cancel()
 
-	return docRef.Update(ctx, updates, preconds...)
+	err = retryWrite(ctx, func() error {
+		result, err = docRef.Update(ctx, updates, preconds...)
+		return err
+	})
+	return result, err
 }
 
-func (c FirestoreClient) SetDoc(ctx context.Context, docRef *firestore.DocumentRef, data interface{}, opts ...firestore.SetOption) (_ *firestore.WriteResult, err error) {
+// CreateDoc is not retried, unlike the other writes. An attempt which failed after the doc was committed would make
+// the retry fail with codes.AlreadyExists, as if another caller created the doc.
+func (c FirestoreClient) CreateDoc(ctx context.Context, docRef *firestore.DocumentRef, data interface{}) (result *firestore.WriteResult, err error) {
 	ctx, cancel := context.WithTimeout(ctx, c.writeTimeout)
 	defer cancel()
 
-	return docRef.Set(ctx, data, opts...)
+	err = breaker.Get(breaker.Database).Do(func() error {
+		result, err = docRef.Create(ctx, data)
+		return err
+	})
+	return result, err
+}
+
+func (c FirestoreClient) SetDoc(ctx context.Context, docRef *firestore.DocumentRef, data interface{}, opts ...firestore.SetOption) (result *firestore.WriteResult, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.writeTimeout)
+	defer cancel()
+
+	err = retryWrite(ctx, func() error {
+		result, err = docRef.Set(ctx, data, opts...)
+		return err
+	})
+	return result, err
 }
 
 func (c FirestoreClient) SetDocs(ctx context.Context, data []DataBatch) (_ []*firestore.WriteResult, err error) {
 	ctx, cancel := context.WithTimeout(ctx, c.writeTimeout)
 	defer cancel()
 
-	batch := c.Client.Batch()
-	for _, item := range data {
-		batch.Set(item.DocRef, item.Data)
-	}
+	var results []*firestore.WriteResult
+	err = retryWrite(ctx, func() error {
+		// a committed batch cannot be reused, so every attempt builds its own
+		batch := c.Client.Batch()
+		for _, item := range data {
+			batch.Set(item.DocRef, item.Data, item.Opts...)
+		}
+
+		results, err = batch.Commit(ctx)
+		return err
+	})
+	return results, err
+}
 
-	return batch.Commit(ctx)
+// retryWrite retries the transient failures of a write, e.g. an unavailable backend or a contention.
+// The write timeout bounds all the attempts.
+// Every attempt goes through the database circuit breaker, so an open breaker stops the retries.
+func retryWrite(ctx context.Context, write utils.CallFunc) error {
+	return utils.NewRetryHandler(time.Second*5, time.Second*2, 3).Do(ctx, func() error {
+		return breaker.Get(breaker.Database).Do(write)
+	})
 }
 
-func (c FirestoreClient) DeleteDoc(ctx context.Context, docRef *firestore.DocumentRef) (_ *firestore.WriteResult, err error) {
+func (c FirestoreClient) DeleteDoc(ctx context.Context, docRef *firestore.DocumentRef) (result *firestore.WriteResult, err error) {
 	ctx, cancel := context.WithTimeout(ctx, c.writeTimeout)
 	defer cancel()
-	colls, err := docRef.Collections(ctx).GetAll()
+
+	var colls []*firestore.CollectionRef
+	err = breaker.Get(breaker.Database).Do(func() error {
+		colls, err = docRef.Collections(ctx).GetAll()
+		return err
+	})
 	if err != nil {
 		log.Error().Err(err).Msgf("failed to get all collections of the doc %s", docRef.Path)
 		return nil, err
@@ -192,11 +252,11 @@ func (c FirestoreClient) DeleteDoc(ctx context.Context, docRef *firestore.Docume
 
 	}
 
-	if docRef != nil {
-		docRef.Delete(ctx)
-	}
-
-	return nil, nil
+	err = retryWrite(ctx, func() error {
+		result, err = docRef.Delete(ctx)
+		return err
+	})
+	return result, err
 }
 
 func (c FirestoreClient) DeleteColl(ctx context.Context, collRef *firestore.CollectionRef) {
@@ -207,6 +267,10 @@ func (c FirestoreClient) DeleteColl(ctx context.Context, collRef *firestore.Coll
 		if err == iterator.Done {
 			return
 		}
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to delete collection %s", collRef.Path)
+			return
+		}
 		c.DeleteDoc(ctx, doc.Ref)
 	}
 }
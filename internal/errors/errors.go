@@ -3,5 +3,6 @@ package errors
 import "fmt"
 
 var (
-	NotFound = fmt.Errorf("Not Found")
+	NotFound      = fmt.Errorf("Not Found")
+	AlreadyExists = fmt.Errorf("Already Exists")
 )
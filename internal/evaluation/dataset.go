@@ -0,0 +1,57 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/videosource"
+)
+
+// Case is a labeled product of a golden dataset.
+type Case struct {
+	Product model.Product `json:"product"`
+	// candidate videos offered to the video evaluation, so that no search is needed
+	CandidateVideos []videosource.Video `json:"candidateVideos"`
+	Expected        Expected            `json:"expected"`
+}
+
+type Expected struct {
+	Sentiments []ExpectedSentiment `json:"sentiments"`
+	// ids of the candidate videos which are relevant to the product
+	VideoIds []string `json:"videoIds"`
+}
+
+type ExpectedSentiment struct {
+	Label string `json:"label"`
+	Score int    `json:"score"`
+}
+
+// LoadDataset reads all the json files of the directory as cases.
+func LoadDataset(dir string) ([]Case, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	cases := make([]Case, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		c := Case{}
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("load case %s: %w", file, err)
+		}
+		if c.Product.Id == nil || c.Product.Name == nil {
+			return nil, fmt.Errorf("load case %s: product id and name are required", file)
+		}
+		cases = append(cases, c)
+	}
+
+	return cases, nil
+}
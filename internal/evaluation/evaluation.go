@@ -0,0 +1,99 @@
+package evaluation
+
+import (
+	"context"
+	"strings"
+
+	"go-firestore-gpt/internal/gpt/usage"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/videosource"
+
+	"github.com/rs/zerolog/log"
+)
+
+type SentimentAnalyzer interface {
+	Analyze(ctx context.Context, product model.Product) ([]model.Sentiment, string, error)
+}
+
+type VideoEvaluator interface {
+	EvaluateVideos(ctx context.Context, relevantVideo model.RelevantVideos, candidates []videosource.Video) ([]model.Video, error)
+}
+
+// Run evaluates the enrichments on the cases. A nil analyzer or evaluator skips the enrichment,
+// as do the cases without expectations for it.
+func Run(ctx context.Context, cases []Case, analyzer SentimentAnalyzer, evaluator VideoEvaluator) Report {
+	report := Report{Cases: len(cases)}
+
+	for _, c := range cases {
+		if analyzer != nil && len(c.Expected.Sentiments) > 0 {
+			evaluateSentiments(ctx, c, analyzer, &report)
+		}
+		if evaluator != nil && len(c.CandidateVideos) > 0 {
+			evaluateVideos(ctx, c, evaluator, &report)
+		}
+	}
+
+	return report
+}
+
+func evaluateSentiments(ctx context.Context, c Case, analyzer SentimentAnalyzer, report *Report) {
+	ctx = usage.WithLabels(ctx, *c.Product.Id, model.EnrichmentReviewSentiments)
+	sentiments, _, err := analyzer.Analyze(ctx, c.Product)
+	if err != nil {
+		log.Error().Err(err).Msgf("evaluation: failed to analyze the sentiments of %s", *c.Product.Id)
+		report.SentimentFailed++
+		return
+	}
+
+	scores := make(map[string]int, len(sentiments))
+	predicted := make([]string, 0, len(sentiments))
+	for _, s := range sentiments {
+		label := normalizeLabel(s.Label)
+		scores[label] = s.Score
+		predicted = append(predicted, label)
+	}
+
+	expected := make([]string, 0, len(c.Expected.Sentiments))
+	for _, s := range c.Expected.Sentiments {
+		label := normalizeLabel(s.Label)
+		expected = append(expected, label)
+		if score, ok := scores[label]; ok {
+			report.SentimentScore.Add(score, s.Score)
+		}
+	}
+
+	log.Debug().Msgf("evaluation: sentiments of %s - predicted %v, expected %v", *c.Product.Id, predicted, expected)
+	report.SentimentLabels.Add(predicted, expected)
+}
+
+func evaluateVideos(ctx context.Context, c Case, evaluator VideoEvaluator, report *Report) {
+	ctx = usage.WithLabels(ctx, *c.Product.Id, model.EnrichmentRelevantVideos)
+	videos, err := evaluator.EvaluateVideos(ctx, model.RelevantVideos{
+		ProductId:   c.Product.Id,
+		ProductName: c.Product.Name,
+	}, c.CandidateVideos)
+	if err != nil {
+		log.Error().Err(err).Msgf("evaluation: failed to evaluate the videos of %s", *c.Product.Id)
+		report.VideosFailed++
+		return
+	}
+
+	// the selected videos only keep their url, so map them back to the candidate ids
+	ids := make(map[string]string, len(c.CandidateVideos))
+	for _, candidate := range c.CandidateVideos {
+		ids[candidate.URL] = candidate.Key()
+	}
+
+	predicted := make([]string, 0, len(videos))
+	for _, video := range videos {
+		predicted = append(predicted, ids[video.Url])
+	}
+
+	log.Debug().Msgf("evaluation: videos of %s - predicted %v, expected %v", *c.Product.Id, predicted, c.Expected.VideoIds)
+	report.Videos.Add(predicted, c.Expected.VideoIds)
+}
+
+// normalizeLabel makes the labels comparable regardless of their case and spacing, e.g. "Easy to use " and "easy to use"
+func normalizeLabel(label string) string {
+	return strings.Join(strings.Fields(strings.ToLower(label)), " ")
+}
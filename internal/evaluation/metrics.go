@@ -0,0 +1,99 @@
+package evaluation
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+)
+
+// SetMetrics counts the matches of predicted items against the expected ones, micro averaged over the cases.
+type SetMetrics struct {
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+}
+
+func (m *SetMetrics) Add(predicted, expected []string) {
+	expectedSet := make(map[string]bool, len(expected))
+	for _, item := range expected {
+		expectedSet[item] = true
+	}
+
+	predictedSet := make(map[string]bool, len(predicted))
+	for _, item := range predicted {
+		if predictedSet[item] {
+			continue
+		}
+		predictedSet[item] = true
+
+		if expectedSet[item] {
+			m.TruePositives++
+		} else {
+			m.FalsePositives++
+		}
+	}
+
+	for item := range expectedSet {
+		if !predictedSet[item] {
+			m.FalseNegatives++
+		}
+	}
+}
+
+func (m SetMetrics) Precision() float64 {
+	return ratio(m.TruePositives, m.TruePositives+m.FalsePositives)
+}
+
+func (m SetMetrics) Recall() float64 {
+	return ratio(m.TruePositives, m.TruePositives+m.FalseNegatives)
+}
+
+// ScoreError is the error of the predicted scores of the matched labels.
+type ScoreError struct {
+	count       int
+	absoluteSum float64
+}
+
+func (e *ScoreError) Add(predicted, expected int) {
+	e.count++
+	e.absoluteSum += math.Abs(float64(predicted - expected))
+}
+
+// MAE is the mean absolute error
+func (e ScoreError) MAE() float64 {
+	if e.count == 0 {
+		return 0
+	}
+	return e.absoluteSum / float64(e.count)
+}
+
+// Report is the evaluation of the enrichments on a dataset.
+type Report struct {
+	Cases           int
+	SentimentFailed int
+	SentimentLabels SetMetrics
+	SentimentScore  ScoreError
+	VideosFailed    int
+	Videos          SetMetrics
+}
+
+func (r Report) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "cases\t%d\n", r.Cases)
+	fmt.Fprintf(tw, "sentiment failures\t%d\n", r.SentimentFailed)
+	fmt.Fprintf(tw, "sentiment label precision\t%.3f\n", r.SentimentLabels.Precision())
+	fmt.Fprintf(tw, "sentiment label recall\t%.3f\n", r.SentimentLabels.Recall())
+	fmt.Fprintf(tw, "sentiment score MAE\t%.3f\n", r.SentimentScore.MAE())
+	fmt.Fprintf(tw, "video evaluation failures\t%d\n", r.VideosFailed)
+	fmt.Fprintf(tw, "video precision\t%.3f\n", r.Videos.Precision())
+	fmt.Fprintf(tw, "video recall\t%.3f\n", r.Videos.Recall())
+	tw.Flush()
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
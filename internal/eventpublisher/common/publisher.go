@@ -6,27 +6,49 @@ import (
 	"sync"
 	"time"
 
+	"go-firestore-gpt/internal/breaker"
 	"go-firestore-gpt/internal/eventpublisher/event"
 )
 
 var ErrWriteFailure = fmt.Errorf("write failure threshold exceeded")
 
+// PublisherWithFailureThreshold gives up on a subscriber once its writes keep timing out.
+// Each subscriber has a circuit breaker which opens once at least writeFailureThreshold writes were made within a
+// minute and at least half of them timed out. Publish then returns ErrWriteFailure, so the subscriber is dropped.
+// The timeouts only count within the minute, so a subscriber whose writes mostly succeed is kept.
 type PublisherWithFailureThreshold struct {
 	writeTimeout          time.Duration
 	writeFailureThreshold int
-	failureCount          map[event.EventWChannel]int
-	failureMu             sync.Mutex
+	breakers              map[event.EventWChannel]*breaker.Breaker
+	breakersMu            sync.Mutex
 }
 
 func NewPublisherWithFailureThreshold(writeTimeout time.Duration, writeFailureThreshold int) *PublisherWithFailureThreshold {
 	return &PublisherWithFailureThreshold{
 		writeTimeout:          writeTimeout,
 		writeFailureThreshold: writeFailureThreshold,
-		failureCount:          make(map[event.EventWChannel]int),
-		failureMu:             sync.Mutex{},
+		breakers:              make(map[event.EventWChannel]*breaker.Breaker),
+		breakersMu:            sync.Mutex{},
 	}
 }
 
+func (p *PublisherWithFailureThreshold) breakerOf(subscriber event.EventWChannel) *breaker.Breaker {
+	p.breakersMu.Lock()
+	defer p.breakersMu.Unlock()
+
+	b, ok := p.breakers[subscriber]
+	if !ok {
+		b = breaker.New("subscriber", breaker.Config{
+			Window:       time.Minute,
+			MinRequests:  p.writeFailureThreshold,
+			FailureRatio: 0.5,
+			OpenTimeout:  time.Minute,
+		})
+		p.breakers[subscriber] = b
+	}
+	return b
+}
+
 func (p *PublisherWithFailureThreshold) Publish(ctx context.Context, subscriber event.EventWChannel, e event.Event) (err error) {
 
 	defer func() {
@@ -41,16 +63,14 @@ func (p *PublisherWithFailureThreshold) Publish(ctx context.Context, subscriber
 	ctx, cancel := context.WithTimeout(ctx, p.writeTimeout)
 	defer cancel()
 
+	b := p.breakerOf(subscriber)
 	select {
 	case subscriber <- e:
+		b.Record(nil)
 		return nil
 	case <-ctx.Done():
-		p.failureMu.Lock()
-		count := p.failureCount[subscriber] + 1
-		p.failureCount[subscriber] = count
-		p.failureMu.Unlock()
-
-		if count >= p.writeFailureThreshold {
+		b.Record(ctx.Err())
+		if b.State() == breaker.StateOpen {
 			err = ErrWriteFailure
 			return
 		}
@@ -14,7 +14,7 @@ import (
 
 const (
 	writeTimeout          = time.Second
-	writeFailureThreshold = 3
+	writeFailureThreshold = 3 // the fewest writes of a minute, half of which time out, to drop a subscriber
 )
 
 type eventFunc func(context.Context) <-chan productRepo.ProductEvent
@@ -0,0 +1,176 @@
+package experiment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/gpt"
+	"go-firestore-gpt/internal/gpt/provider"
+	"go-firestore-gpt/internal/gpt/usage"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/prompt"
+	experimentsRepository "go-firestore-gpt/internal/repository/experiments"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	VariantControl   string = "control"
+	VariantTreatment string = "treatment"
+
+	// model name of a variant using the chain of the enrichment
+	defaultModel string = "default"
+)
+
+// Variant is a way of running an enrichment, i.e. with its prompt versions and models.
+type Variant struct {
+	Name           string
+	Model          string
+	GptFactory     gpt.ClientFactory
+	PromptVersions map[string]int // pinned prompt versions, the others use the registry default
+}
+
+// Render renders the prompt with the version pinned by the variant.
+func (v Variant) Render(prompts *prompt.Registry, name string, data interface{}) (prompt.Prompt, error) {
+	if version, ok := v.PromptVersions[name]; ok {
+		return prompts.RenderVersion(name, version, data)
+	}
+	return prompts.Render(name, data)
+}
+
+// Control is the variant which runs the enrichment as configured.
+func Control(gptFactory gpt.ClientFactory) Variant {
+	return Variant{Name: VariantControl, Model: defaultModel, GptFactory: gptFactory}
+}
+
+// Outcome is the output of a variant run.
+type Outcome struct {
+	Items          []string // comparable items, e.g. sentiment labels or video urls
+	Output         interface{}
+	PromptVersions map[string]string
+}
+
+// Experiment processes a fraction of the products of an enrichment with an alternative variant
+// in addition to the control one, and records the outcome of both.
+type Experiment struct {
+	name           string
+	enrichment     string
+	fraction       float64
+	model          string
+	gptFactory     gpt.ClientFactory
+	promptVersions map[string]int
+	repo           experimentsRepository.IRepository
+}
+
+// New creates the experiment of the config. It returns nil if no experiment is configured.
+// The clients of an alternative model are created with the client config of the control, so that only the model differs.
+func New(cnf config.Experiment, registry *provider.Registry, clientConfig gpt.ClientConfig, repo experimentsRepository.IRepository) (*Experiment, error) {
+	if cnf.Name == "" {
+		return nil, nil
+	}
+	if cnf.Fraction < 0 || cnf.Fraction > 1 {
+		return nil, fmt.Errorf("experiment fraction %v must be between 0 and 1", cnf.Fraction)
+	}
+
+	e := &Experiment{
+		name:           cnf.Name,
+		enrichment:     cnf.Enrichment,
+		fraction:       cnf.Fraction,
+		model:          defaultModel,
+		promptVersions: make(map[string]int),
+		repo:           repo,
+	}
+
+	for _, spec := range cnf.PromptVersions {
+		name, version, found := strings.Cut(strings.TrimSpace(spec), "=")
+		v, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+		if !found || err != nil {
+			return nil, fmt.Errorf("experiment prompt version '%s' must be formatted as 'name=v2'", spec)
+		}
+		e.promptVersions[name] = v
+	}
+	// the treatment evaluates the videos searched by the control, so the search quota is not spent twice
+	if _, ok := e.promptVersions[prompt.ProductNameExtraction]; ok && cnf.Enrichment == model.EnrichmentRelevantVideos {
+		return nil, fmt.Errorf("experiment of %s can not pin the %s prompt, the treatment reuses the searched videos",
+			model.EnrichmentRelevantVideos, prompt.ProductNameExtraction)
+	}
+
+	if cnf.Model != "" {
+		targets, err := gpt.ParseTargets([]string{cnf.Model}, registry)
+		if err != nil {
+			return nil, err
+		}
+		factory, err := gpt.NewClientFactory(targets, nil, clientConfig)
+		if err != nil {
+			return nil, err
+		}
+		e.model = cnf.Model
+		e.gptFactory = factory
+	}
+
+	return e, nil
+}
+
+// Applies reports whether the product takes part in the experiment of the enrichment.
+// The selection is deterministic, so a product stays in the same group when it is processed again.
+func (e *Experiment) Applies(enrichment, productId string) bool {
+	if e == nil || e.enrichment != enrichment {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(e.name + "/" + productId))
+	bucket := float64(binary.BigEndian.Uint64(sum[:8])) / math.MaxUint64
+	return bucket < e.fraction
+}
+
+// Treatment is the alternative variant. It falls back to the given factory if the experiment does not change the model.
+func (e *Experiment) Treatment(control gpt.ClientFactory) Variant {
+	factory := e.gptFactory
+	if factory == nil {
+		factory = control
+	}
+	return Variant{Name: VariantTreatment, Model: e.model, GptFactory: factory, PromptVersions: e.promptVersions}
+}
+
+// Observe runs the variant and records its outcome. The error of the run is returned as is.
+func (e *Experiment) Observe(ctx context.Context, productId string, v Variant, run func(context.Context) (Outcome, error)) error {
+	ctx, collector := usage.WithCollector(ctx)
+
+	start := time.Now()
+	outcome, err := run(ctx)
+	duration := time.Since(start)
+
+	result := model.ExperimentResult{
+		Experiment:     e.name,
+		Enrichment:     e.enrichment,
+		ProductId:      productId,
+		Variant:        v.Name,
+		Model:          v.Model,
+		PromptVersions: outcome.PromptVersions,
+		Items:          outcome.Items,
+		Duration:       duration,
+	}
+	result.Calls, result.PromptTokens, result.CompletionTokens, result.Cost = collector.Snapshot()
+
+	if err != nil {
+		result.Failed = true
+		result.Error = err.Error()
+	} else if output, marshalErr := json.Marshal(outcome.Output); marshalErr == nil {
+		result.Output = string(output)
+		result.OutputSize = len(outcome.Items)
+	}
+
+	if createErr := e.repo.Create(ctx, result); createErr != nil {
+		log.Error().Err(createErr).Msgf("failed to record the %s variant of experiment %s", v.Name, e.name)
+	}
+
+	return err
+}
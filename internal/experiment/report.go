@@ -0,0 +1,136 @@
+package experiment
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"go-firestore-gpt/internal/model"
+)
+
+// VariantSummary aggregates the results of a variant.
+type VariantSummary struct {
+	Variant        string
+	Model          string
+	Products       int
+	Failures       int
+	MeanOutputSize float64
+	TotalCost      float64
+	MeanCost       float64
+	MeanDuration   float64 // seconds
+}
+
+// Report compares the variants of an experiment.
+type Report struct {
+	Experiment string
+	Variants   []VariantSummary
+	// products where both variants succeeded
+	Compared int
+	// mean Jaccard similarity of the items of the variants
+	MeanAgreement float64
+	// fraction of the compared products whose variants produced the same items
+	ExactMatches float64
+}
+
+// Summarize builds the report of the results of an experiment.
+func Summarize(experiment string, results []model.ExperimentResult) Report {
+	report := Report{Experiment: experiment}
+
+	byVariant := make(map[string][]model.ExperimentResult)
+	byProduct := make(map[string]map[string]model.ExperimentResult)
+	for _, r := range results {
+		byVariant[r.Variant] = append(byVariant[r.Variant], r)
+		if _, ok := byProduct[r.ProductId]; !ok {
+			byProduct[r.ProductId] = make(map[string]model.ExperimentResult)
+		}
+		byProduct[r.ProductId][r.Variant] = r
+	}
+
+	for variant, rs := range byVariant {
+		summary := VariantSummary{Variant: variant, Products: len(rs)}
+		succeeded := 0
+		for _, r := range rs {
+			summary.Model = r.Model
+			summary.TotalCost += r.Cost
+			summary.MeanDuration += r.Duration.Seconds()
+			if r.Failed {
+				summary.Failures++
+				continue
+			}
+			succeeded++
+			summary.MeanOutputSize += float64(r.OutputSize)
+		}
+		if succeeded > 0 {
+			summary.MeanOutputSize /= float64(succeeded)
+		}
+		summary.MeanCost = summary.TotalCost / float64(len(rs))
+		summary.MeanDuration /= float64(len(rs))
+		report.Variants = append(report.Variants, summary)
+	}
+
+	sort.Slice(report.Variants, func(i, j int) bool {
+		return report.Variants[i].Variant < report.Variants[j].Variant
+	})
+
+	exact := 0
+	for _, variants := range byProduct {
+		control, ok1 := variants[VariantControl]
+		treatment, ok2 := variants[VariantTreatment]
+		if !ok1 || !ok2 || control.Failed || treatment.Failed {
+			continue
+		}
+
+		agreement := jaccard(control.Items, treatment.Items)
+		report.Compared++
+		report.MeanAgreement += agreement
+		if agreement == 1 {
+			exact++
+		}
+	}
+
+	if report.Compared > 0 {
+		report.MeanAgreement /= float64(report.Compared)
+		report.ExactMatches = float64(exact) / float64(report.Compared)
+	}
+
+	return report
+}
+
+func (r Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "experiment: %s\n\n", r.Experiment)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "variant\tmodel\tproducts\tfailures\tmean output size\ttotal cost (USD)\tmean cost (USD)\tmean duration (s)")
+	for _, v := range r.Variants {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.2f\t%.4f\t%.6f\t%.2f\n",
+			v.Variant, v.Model, v.Products, v.Failures, v.MeanOutputSize, v.TotalCost, v.MeanCost, v.MeanDuration)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\ncompared products: %d\nmean agreement: %.2f\nexact matches: %.2f\n",
+		r.Compared, r.MeanAgreement, r.ExactMatches)
+}
+
+// jaccard returns the similarity of two sets of items, two empty sets are identical
+func jaccard(a, b []string) float64 {
+	set := make(map[string]int)
+	for _, item := range a {
+		set[item] |= 1
+	}
+	for _, item := range b {
+		set[item] |= 2
+	}
+
+	if len(set) == 0 {
+		return 1
+	}
+
+	intersection := 0
+	for _, v := range set {
+		if v == 3 {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(set))
+}
@@ -0,0 +1,315 @@
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	searchPath = "/youtube/v3/search"
+	videosPath = "/youtube/v3/videos"
+)
+
+// Server fakes the OpenAI compatible chat completions endpoint and the YouTube Data API search.list and
+// videos.list endpoints. The details of videos.list are those of the videos of the search rules.
+// Point GILAS_API_URL (or any other OpenAI compatible provider url) and YOUTUBE_API_URL at it.
+type Server struct {
+	mu       sync.Mutex
+	script   Script
+	chats    int
+	searches int
+
+	httpServer *httptest.Server
+}
+
+func New(script Script) *Server {
+	return &Server{script: script}
+}
+
+// Start serves on a random local port and returns the url of the server.
+func (s *Server) Start() string {
+	s.httpServer = httptest.NewServer(s)
+	return s.httpServer.URL
+}
+
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// OnChat appends chat rules to the script.
+func (s *Server) OnChat(rules ...ChatRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script.Chat = append(s.script.Chat, rules...)
+}
+
+// OnSearch appends search rules to the script.
+func (s *Server) OnSearch(rules ...SearchRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script.Search = append(s.script.Search, rules...)
+}
+
+// Requests returns the number of chat completion and search requests received so far.
+func (s *Server) Requests() (chats, searches int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.chats, s.searches
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/chat/completions"):
+		s.handleChat(w, r)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, searchPath):
+		s.handleSearch(w, r)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, videosPath):
+		s.handleVideos(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	req := chatRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf(`{"error":{"message":%q}}`, err.Error()))
+		return
+	}
+
+	text := strings.Builder{}
+	for _, m := range req.Messages {
+		text.WriteString(m.Content)
+		text.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	s.chats++
+	rule, ok := s.matchChat(text.String())
+	latency := s.script.Latency
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotImplemented, `{"error":{"message":"no scripted chat response matches the request"}}`)
+		return
+	}
+
+	sleep(r, latency+rule.Latency)
+	if isFailure(rule.StatusCode) {
+		writeFault(w, rule.Fault, `{"error":{"message":"injected failure"}}`)
+		return
+	}
+
+	promptTokens := len(strings.Fields(text.String()))
+	completionTokens := len(strings.Fields(rule.Content))
+	writeJSON(w, map[string]interface{}{
+		"id":      fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano()),
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   req.Model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       chatMessage{Role: "assistant", Content: rule.Content},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]int{
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+			"total_tokens":      promptTokens + completionTokens,
+		},
+	})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+
+	s.mu.Lock()
+	s.searches++
+	rule, ok := s.matchSearch(query)
+	latency := s.script.Latency
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotImplemented, googleError(http.StatusNotImplemented, "notImplemented", "no scripted search response matches the request"))
+		return
+	}
+
+	sleep(r, latency+rule.Latency)
+	if isFailure(rule.StatusCode) {
+		reason := rule.Reason
+		if reason == "" {
+			reason = "backendError"
+		}
+		writeFault(w, rule.Fault, googleError(rule.StatusCode, reason, "injected failure"))
+		return
+	}
+
+	items := make([]map[string]interface{}, 0, len(rule.Videos))
+	for _, v := range rule.Videos {
+		items = append(items, map[string]interface{}{
+			"kind": "youtube#searchResult",
+			"id":   map[string]string{"kind": "youtube#video", "videoId": v.Id},
+			"snippet": map[string]string{
+				"title":       v.Title,
+				"description": v.Description,
+				"channelId":   v.ChannelId,
+			},
+		})
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"kind":  "youtube#searchListResponse",
+		"items": items,
+		"pageInfo": map[string]int{
+			"totalResults":   len(items),
+			"resultsPerPage": len(items),
+		},
+	})
+}
+
+func (s *Server) handleVideos(w http.ResponseWriter, r *http.Request) {
+	ids := map[string]bool{}
+	for _, param := range r.URL.Query()["id"] {
+		for _, id := range strings.Split(param, ",") {
+			ids[id] = true
+		}
+	}
+
+	s.mu.Lock()
+	videos := map[string]Video{}
+	for _, rule := range s.script.Search {
+		for _, v := range rule.Videos {
+			if ids[v.Id] {
+				videos[v.Id] = v
+			}
+		}
+	}
+	latency := s.script.Latency
+	s.mu.Unlock()
+
+	sleep(r, latency)
+
+	items := make([]map[string]interface{}, 0, len(videos))
+	for _, v := range videos {
+		items = append(items, map[string]interface{}{
+			"kind": "youtube#video",
+			"id":   v.Id,
+			"snippet": map[string]interface{}{
+				"title":                v.Title,
+				"description":          v.Description,
+				"channelId":            v.ChannelId,
+				"channelTitle":         v.ChannelTitle,
+				"publishedAt":          v.PublishedAt,
+				"defaultAudioLanguage": v.Language,
+				"thumbnails": map[string]interface{}{
+					"high": map[string]string{"url": fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", v.Id)},
+				},
+			},
+			"contentDetails": map[string]string{"duration": v.Duration},
+			"statistics": map[string]string{
+				"viewCount": fmt.Sprint(v.ViewCount),
+				"likeCount": fmt.Sprint(v.LikeCount),
+			},
+		})
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"kind":  "youtube#videoListResponse",
+		"items": items,
+	})
+}
+
+// matchChat returns the first matching rule and consumes one of its uses. It must be called with the lock held.
+func (s *Server) matchChat(text string) (ChatRule, bool) {
+	for i := range s.script.Chat {
+		rule := &s.script.Chat[i]
+		if rule.Times < 0 || !strings.Contains(text, rule.Match) {
+			continue
+		}
+		consume(&rule.Times)
+		return *rule, true
+	}
+	return ChatRule{}, false
+}
+
+// matchSearch returns the first matching rule and consumes one of its uses. It must be called with the lock held.
+func (s *Server) matchSearch(query string) (SearchRule, bool) {
+	for i := range s.script.Search {
+		rule := &s.script.Search[i]
+		if rule.Times < 0 || !strings.Contains(query, rule.Match) {
+			continue
+		}
+		consume(&rule.Times)
+		return *rule, true
+	}
+	return SearchRule{}, false
+}
+
+// consume decrements the uses of a limited rule, an exhausted rule is marked with a negative count
+func consume(times *int) {
+	switch {
+	case *times == 1:
+		*times = -1
+	case *times > 1:
+		*times--
+	}
+}
+
+func isFailure(statusCode int) bool {
+	return statusCode != 0 && (statusCode < 200 || statusCode > 299)
+}
+
+func sleep(r *http.Request, d Duration) {
+	if d <= 0 {
+		return
+	}
+
+	select {
+	case <-r.Context().Done():
+	case <-time.After(time.Duration(d)):
+	}
+}
+
+func googleError(code int, reason, message string) string {
+	return fmt.Sprintf(`{"error":{"code":%d,"message":%q,"errors":[{"reason":%q,"message":%q}]}}`, code, message, reason, message)
+}
+
+func writeFault(w http.ResponseWriter, fault Fault, defaultBody string) {
+	if fault.RetryAfter != "" {
+		w.Header().Set("Retry-After", fault.RetryAfter)
+	}
+
+	body := fault.Body
+	if body == "" {
+		body = defaultBody
+	}
+	writeError(w, fault.StatusCode, body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write([]byte(body))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,125 @@
+package fakeserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/fakeserver"
+	"go-firestore-gpt/internal/gpt/provider"
+	"go-firestore-gpt/internal/youtube"
+
+	"google.golang.org/api/googleapi"
+)
+
+func complete(t *testing.T, p provider.Provider, content string) (provider.Response, error) {
+	t.Helper()
+	return p.Complete(context.Background(), provider.Request{Messages: []provider.Message{{Role: provider.RoleUser, Content: content}}})
+}
+
+func TestChat(t *testing.T) {
+	server := fakeserver.New(fakeserver.Script{Chat: []fakeserver.ChatRule{
+		{Match: "Product name:", Content: `{"ids": ["fake-video-1"]}`},
+		{Fault: fakeserver.Fault{StatusCode: http.StatusTooManyRequests, RetryAfter: "1"}, Times: 1},
+		{Content: "Fake Product"},
+	}})
+	url := server.Start()
+	defer server.Close()
+
+	cnf := config.Config{OpenAI: config.OpenAI{ApiKey: "test", ApiUrl: url + "/v1", Model: "gpt-4o-mini"}}
+	p, err := provider.New(provider.OpenAI, cnf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := complete(t, p, "Product name: 'Kindle'")
+	if err != nil || resp.Content != `{"ids": ["fake-video-1"]}` {
+		t.Errorf("matching rule: got %q, %v", resp.Content, err)
+	}
+
+	// the injected failure answers a single request, then the next rule answers
+	_, err = complete(t, p, "Extract the product name")
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusTooManyRequests || httpErr.Header.Get("Retry-After") != "1" {
+		t.Errorf("injected failure: got %v", err)
+	}
+	resp, err = complete(t, p, "Extract the product name")
+	if err != nil || resp.Content != "Fake Product" {
+		t.Errorf("after the limited rule: got %q, %v", resp.Content, err)
+	}
+
+	if chats, searches := server.Requests(); chats != 3 || searches != 0 {
+		t.Errorf("got %d chats and %d searches, want 3 and 0", chats, searches)
+	}
+}
+
+func TestChatWithoutMatchingRule(t *testing.T) {
+	server := fakeserver.New(fakeserver.Script{Chat: []fakeserver.ChatRule{{Match: "Product name:", Content: "{}"}}})
+	url := server.Start()
+	defer server.Close()
+
+	p, err := provider.New(provider.OpenAI, config.Config{OpenAI: config.OpenAI{ApiUrl: url}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = complete(t, p, "Analyze a list of reviews")
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotImplemented {
+		t.Errorf("got %v, want status %d", err, http.StatusNotImplemented)
+	}
+}
+
+// The YouTube client is a singleton, so it is only created by this test.
+func TestYouTube(t *testing.T) {
+	video := fakeserver.Video{
+		Id: "fake-video-1", Title: "Fake product review", Duration: "PT6M30S", ViewCount: 120000, LikeCount: 3400,
+		ChannelId: "UCfake", ChannelTitle: "Fake Reviews", PublishedAt: "2023-05-01T10:00:00Z", Language: "en",
+	}
+	server := fakeserver.New(fakeserver.Script{Search: []fakeserver.SearchRule{
+		{Match: "exhausted", Reason: "quotaExceeded", Fault: fakeserver.Fault{StatusCode: http.StatusForbidden}},
+		{Fault: fakeserver.Fault{StatusCode: http.StatusServiceUnavailable, RetryAfter: "1"}, Times: 1},
+		{Videos: []fakeserver.Video{video}},
+	}})
+	url := server.Start()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := youtube.NewYouTubeClient(ctx, config.Youtube{ApiKey: "test", ApiUrl: url + "/"}, nil, nil, nil)
+	if client == nil {
+		t.Fatal("no youtube client")
+	}
+
+	// an exhausted quota is not retried
+	_, err := client.Search(ctx, "exhausted quota", 5, youtube.SearchOptions{})
+	var gErr *googleapi.Error
+	if !errors.As(err, &gErr) || gErr.Code != http.StatusForbidden || len(gErr.Errors) == 0 || gErr.Errors[0].Reason != "quotaExceeded" {
+		t.Errorf("quota failure: got %v", err)
+	}
+	if _, searches := server.Requests(); searches != 1 {
+		t.Errorf("got %d searches, want the exhausted quota not retried", searches)
+	}
+
+	// the injected failure answers the first attempt only, the retry is answered by the videos
+	videos, err := client.Search(ctx, "fake product review", 5, youtube.SearchOptions{})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(videos) != 1 || videos[0].ID != video.Id || videos[0].Title != video.Title {
+		t.Errorf("unexpected videos %+v", videos)
+	}
+	if _, searches := server.Requests(); searches != 3 {
+		t.Errorf("got %d searches, want the failed search retried once", searches)
+	}
+
+	detailed, err := client.Details(ctx, videos)
+	if err != nil {
+		t.Fatalf("details: %v", err)
+	}
+	details := detailed[0].Details
+	if details == nil || details.ViewCount != 120000 || details.ChannelTitle != "Fake Reviews" || details.Duration.String() != "6m30s" {
+		t.Errorf("unexpected details %+v", details)
+	}
+}
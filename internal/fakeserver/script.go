@@ -0,0 +1,82 @@
+package fakeserver
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Script configures the responses of the fake server. The rules are evaluated in order and the first
+// matching one answers the request.
+type Script struct {
+	// latency added to every response
+	Latency Duration     `json:"latency"`
+	Chat    []ChatRule   `json:"chat"`
+	Search  []SearchRule `json:"search"`
+}
+
+// Fault injects an error or latency into the responses of a rule.
+type Fault struct {
+	// a non 2xx status code fails the request with the body, or with a default error body if it is empty
+	StatusCode int      `json:"statusCode"`
+	Body       string   `json:"body"`
+	Latency    Duration `json:"latency"`
+	// sent as the Retry-After header of the failure
+	RetryAfter string `json:"retryAfter"`
+}
+
+// ChatRule answers the chat completions whose messages contain Match, an empty match answers all of them.
+type ChatRule struct {
+	Match   string `json:"match"`
+	Content string `json:"content"`
+	Fault
+	// number of requests answered by the rule, zero answers all of them
+	Times int `json:"times"`
+}
+
+// SearchRule answers the YouTube searches whose query contains Match, an empty match answers all of them.
+type SearchRule struct {
+	Match  string  `json:"match"`
+	Videos []Video `json:"videos"`
+	// reason of a failure, e.g. quotaExceeded
+	Reason string `json:"reason"`
+	Fault
+	// number of requests answered by the rule, zero answers all of them
+	Times int `json:"times"`
+}
+
+// Video is returned by the searches, and its details by videos.list
+type Video struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// ISO 8601 duration, e.g. PT4M13S
+	Duration     string `json:"duration"`
+	ViewCount    uint64 `json:"viewCount"`
+	LikeCount    uint64 `json:"likeCount"`
+	ChannelId    string `json:"channelId"`
+	ChannelTitle string `json:"channelTitle"`
+	// RFC 3339 date, e.g. 2023-01-02T15:04:05Z
+	PublishedAt string `json:"publishedAt"`
+	Language    string `json:"language"`
+}
+
+// Duration is a time.Duration formatted as a string in JSON, e.g. "250ms"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"go-firestore-gpt/internal/model"
+	llmCacheRepository "go-firestore-gpt/internal/repository/llmcache"
+)
+
+type databaseStore struct {
+	repo llmCacheRepository.IRepository
+}
+
+func NewDatabaseStore(repo llmCacheRepository.IRepository) Store {
+	return &databaseStore{repo: repo}
+}
+
+func (s *databaseStore) Get(ctx context.Context, key string) (string, bool, error) {
+	entry, err := s.repo.GetById(ctx, key)
+	if err != nil || entry == nil {
+		return "", false, err
+	}
+
+	if time.Now().After(entry.ExpiresAt) {
+		return "", false, nil
+	}
+
+	return entry.Value, true, nil
+}
+
+func (s *databaseStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.repo.Set(ctx, model.LLMCacheEntry{
+		Key:       &key,
+		Value:     value,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	})
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type diskEntry struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// diskStore keeps every entry in its own file named after the key.
+type diskStore struct {
+	dir string
+}
+
+func NewDiskStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	return &diskStore{dir: dir}, nil
+}
+
+func (s *diskStore) Get(_ context.Context, key string) (string, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	entry := diskEntry{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", false, err
+	}
+
+	if time.Now().After(entry.ExpiresAt) {
+		os.Remove(s.path(key))
+		return "", false, nil
+	}
+
+	return entry.Value, true, nil
+}
+
+func (s *diskStore) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	data, err := json.Marshal(diskEntry{Value: value, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file first so that readers never see a partial entry
+	tmp := s.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(key))
+}
+
+func (s *diskStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
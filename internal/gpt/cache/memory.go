@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	entries map[string]memoryEntry
+	mu      sync.RWMutex
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[string]memoryEntry),
+		mu:      sync.RWMutex{},
+	}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (string, bool, error) {
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return "", false, nil
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		s.mu.Lock()
+		delete(s.entries, key)
+		s.mu.Unlock()
+		return "", false, nil
+	}
+
+	return entry.value, true, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
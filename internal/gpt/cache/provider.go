@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-firestore-gpt/internal/gpt/provider"
+	"go-firestore-gpt/internal/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+type cachedProvider struct {
+	provider.Provider
+	store Store
+	ttl   time.Duration
+}
+
+// Middleware caches the responses of the decorated provider in the store.
+// Failures of the store are logged and never fail the request.
+func Middleware(store Store, ttl time.Duration) provider.Middleware {
+	return func(p provider.Provider) provider.Provider {
+		return &cachedProvider{Provider: p, store: store, ttl: ttl}
+	}
+}
+
+func (p *cachedProvider) Complete(ctx context.Context, req provider.Request) (provider.Response, error) {
+	if req.Model == "" {
+		// a change of the default model must not serve the responses of the previous model
+		req.Model = p.DefaultModel()
+	}
+	key := Key(p.Name(), req)
+
+	if value, ok, err := p.store.Get(ctx, key); err != nil {
+		log.Error().Err(err).Msg("failed to read llm cache")
+	} else if ok {
+		resp := provider.Response{}
+		if err := json.Unmarshal([]byte(value), &resp); err == nil {
+			log.Debug().Msgf("llm cache hit %s", key)
+			return resp, nil
+		}
+	}
+
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if value, err := json.Marshal(resp); err == nil {
+		if err := p.store.Set(ctx, key, string(value), p.ttl); err != nil {
+			log.Error().Err(err).Msg("failed to write llm cache")
+		}
+	}
+
+	return resp, nil
+}
+
+// Key hashes the provider, the model, the sampling parameters and the whole conversation (instruction and prompts)
+// of the request. The model of the request must be resolved, i.e. set to the default model of the provider if empty.
+func Key(providerName string, req provider.Request) string {
+	sb := strings.Builder{}
+	sb.WriteString(providerName)
+	sb.WriteString("\n")
+	sb.WriteString(req.Model)
+	sb.WriteString("\n")
+	if req.Temperature != nil {
+		sb.WriteString(strconv.FormatFloat(float64(*req.Temperature), 'g', -1, 32))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(strconv.Itoa(req.MaxTokens))
+	for _, m := range req.Messages {
+		sb.WriteString("\n")
+		sb.WriteString(m.Role)
+		sb.WriteString(":")
+		sb.WriteString(m.Content)
+	}
+	return utils.Hash(sb.String())
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	llmCacheRepository "go-firestore-gpt/internal/repository/llmcache"
+)
+
+const (
+	// names of the supported stores
+	StoreNone     string = "none"
+	StoreMemory   string = "memory"
+	StoreDisk     string = "disk"
+	StoreDatabase string = "database"
+)
+
+// Store keeps values for a limited time. Get reports whether an unexpired value exists for the key.
+type Store interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// NewStore creates the store selected in the config. It returns a nil store if caching is disabled.
+func NewStore(cnf config.LLMCache, repo llmCacheRepository.IRepository) (Store, error) {
+	switch cnf.Store {
+	case "", StoreNone:
+		return nil, nil
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StoreDisk:
+		return NewDiskStore(cnf.Dir)
+	case StoreDatabase:
+		return NewDatabaseStore(repo), nil
+	}
+
+	return nil, fmt.Errorf("unknown cache store '%s'", cnf.Store)
+}
@@ -0,0 +1,11 @@
+package gpt
+
+const (
+	// Tasks which can be configured with their own models
+	TaskSentimentAnalysis     string = "sentiment-analysis"
+	TaskProductNameExtraction string = "product-name-extraction"
+	TaskVideoEvaluation       string = "video-evaluation"
+
+	// number of attempts on a target before falling back to the next one
+	maxAttemptsPerTarget = 3
+)
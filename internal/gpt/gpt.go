@@ -1,42 +1,169 @@
 package gpt
 
 import (
-	"sync"
+	"context"
+	"fmt"
+	"time"
 
-	gpt "github.com/m-ariany/gpt-chat-client"
-)
+	"go-firestore-gpt/internal/gpt/provider"
+	"go-firestore-gpt/internal/utils"
 
-var (
-	client *gpt.Client
-	once   sync.Once
+	"github.com/rs/zerolog/log"
 )
 
 type ClientFactory interface {
 	Client() (Client, error)
 	ClientWithConfig(ClientConfig) (Client, error)
+	ClientForTask(task string) (Client, error)
+}
+
+type ClientConfig struct {
+	// Model overrides the model of the first target of the chain
+	Model       string
+	Temperature *float32
+	MaxTokens   int
 }
 
 type factory struct {
+	chain  []Target
+	tasks  map[string][]Target
+	config ClientConfig
 }
 
-func NewClientFactory(cnf ClientConfig) (ClientFactory, error) {
-	var err error
-	once.Do(func() {
-		client, err = gpt.NewClient(cnf)
-	})
-	return &factory{}, err
+// NewClientFactory creates a factory whose clients try the targets of the chain in order.
+// The tasks map overrides the chain for specific tasks, the default chain is used as their fallback.
+func NewClientFactory(chain []Target, tasks map[string][]Target, cnf ClientConfig) (ClientFactory, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("gpt client factory: empty target chain")
+	}
+	return &factory{chain: chain, tasks: tasks, config: cnf}, nil
 }
 
 func (g factory) Client() (Client, error) {
-	return Client{Client: client.Clone()}, nil
+	return newClient(g.chain, g.config), nil
 }
 
+// ClientWithConfig creates a client inheriting the factory config while allowing overrides through the given config.
 func (g factory) ClientWithConfig(cnf ClientConfig) (Client, error) {
-	return Client{Client: client.CloneWithConfig(cnf)}, nil
+	merged := g.config
+	if cnf.Model != "" {
+		merged.Model = cnf.Model
+	}
+	if cnf.Temperature != nil {
+		merged.Temperature = cnf.Temperature
+	}
+	if cnf.MaxTokens > 0 {
+		merged.MaxTokens = cnf.MaxTokens
+	}
+	return newClient(g.chain, merged), nil
+}
+
+// ClientForTask creates a client using the chain configured for the task followed by the default chain.
+func (g factory) ClientForTask(task string) (Client, error) {
+	chain, ok := g.tasks[task]
+	if !ok || len(chain) == 0 {
+		return g.Client()
+	}
+
+	cnf := g.config
+	cnf.Model = ""
+	return newClient(append(append([]Target{}, chain...), g.chain...), cnf), nil
 }
 
+// Client keeps the conversation with a chain of targets. Note that clients are not concurrency-safe.
 type Client struct {
-	*gpt.Client
+	*conversation
+}
+
+type conversation struct {
+	chain   []Target
+	current int
+	config  ClientConfig
+	history []provider.Message
+}
+
+func newClient(chain []Target, cnf ClientConfig) Client {
+	if cnf.Model != "" {
+		chain = append([]Target{{Provider: chain[0].Provider, Model: cnf.Model}}, chain[1:]...)
+	}
+	return Client{conversation: &conversation{chain: chain, config: cnf}}
+}
+
+// Instruct sets the system message of the conversation.
+func (c *conversation) Instruct(instruction string) error {
+	msg := provider.Message{Role: provider.RoleSystem, Content: instruction}
+	if len(c.history) > 0 && c.history[0].Role == provider.RoleSystem {
+		c.history[0] = msg
+		return nil
+	}
+	c.history = append([]provider.Message{msg}, c.history...)
+	return nil
+}
+
+// Prompt sends the prompt along with the conversation history and returns the response.
+// If the current target keeps failing, the conversation falls back to the next target of the chain.
+func (c *conversation) Prompt(ctx context.Context, prompt string) (string, error) {
+	c.history = append(c.history, provider.Message{Role: provider.RoleUser, Content: prompt})
+
+	for {
+		resp, err := c.complete(ctx)
+		if err == nil {
+			c.history = append(c.history, provider.Message{Role: provider.RoleAssistant, Content: resp.Content})
+			return resp.Content, nil
+		}
+
+		if ctx.Err() != nil || c.current+1 >= len(c.chain) {
+			// drop the prompt so that the conversation stays consistent
+			c.history = c.history[:len(c.history)-1]
+			return "", err
+		}
+
+		log.Error().Err(err).Msgf("falling back from %s to %s", c.chain[c.current], c.chain[c.current+1])
+		c.current++
+	}
+}
+
+// Fallback restarts the conversation from its instruction on the next target of the chain.
+// It returns false if there is no target left.
+func (c *conversation) Fallback() bool {
+	if c.current+1 >= len(c.chain) {
+		return false
+	}
+
+	log.Debug().Msgf("falling back from %s to %s", c.chain[c.current], c.chain[c.current+1])
+	c.current++
+	if len(c.history) > 0 && c.history[0].Role == provider.RoleSystem {
+		c.history = c.history[:1]
+	} else {
+		c.history = nil
+	}
+	return true
 }
 
-type ClientConfig = gpt.ClientConfig
+// Target returns the target currently serving the conversation.
+func (c *conversation) Target() Target {
+	return c.chain[c.current]
+}
+
+func (c *conversation) complete(ctx context.Context) (provider.Response, error) {
+	target := c.chain[c.current]
+
+	var resp provider.Response
+
+	retryHandler := utils.NewRetryHandler(time.Second*5, time.Second*2, maxAttemptsPerTarget)
+	err := retryHandler.Do(ctx, func() error {
+		var err error
+		resp, err = target.Provider.Complete(ctx, provider.Request{
+			Model:       target.Model,
+			Messages:    c.history,
+			Temperature: c.config.Temperature,
+			MaxTokens:   c.config.MaxTokens,
+		})
+		if err != nil {
+			log.Error().Err(err).Msgf("failed calling %s", target)
+		}
+		return err
+	})
+
+	return resp, err
+}
@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-firestore-gpt/internal/config"
+)
+
+// anthropic talks to an Anthropic style messages API.
+type anthropic struct {
+	cnf        config.Anthropic
+	url        string
+	httpClient *http.Client
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature *float32           `json:"temperature,omitempty"`
+	MaxTokens   int                `json:"max_tokens"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func NewAnthropic(cnf config.Anthropic, httpClient *http.Client) Provider {
+	url := strings.TrimSuffix(cnf.ApiUrl, "/")
+	if !strings.HasSuffix(url, "/messages") {
+		url += "/messages"
+	}
+
+	return &anthropic{
+		cnf:        cnf,
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+func (p *anthropic) Name() string {
+	return Anthropic
+}
+
+func (p *anthropic) DefaultModel() string {
+	return p.cnf.Model
+}
+
+func (p *anthropic) Complete(ctx context.Context, req Request) (Response, error) {
+	body := anthropicRequest{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+	if body.Model == "" {
+		body.Model = p.DefaultModel()
+	}
+	// max_tokens is mandatory in the messages API
+	if body.MaxTokens == 0 {
+		body.MaxTokens = p.cnf.MaxTokens
+	}
+
+	// The system message is not part of the messages but a top level field
+	for _, m := range req.Messages {
+		if m.Role == RoleSystem {
+			body.System = m.Content
+			continue
+		}
+		// empty messages are rejected by the API
+		if m.Content == "" {
+			continue
+		}
+		body.Messages = append(body.Messages, anthropicMessage{Role: m.Role, Content: m.Content})
+	}
+
+	// The messages API requires the conversation to start with a user message
+	if len(body.Messages) == 0 || body.Messages[0].Role != RoleUser {
+		body.Messages = append([]anthropicMessage{{Role: RoleUser, Content: "Follow the instructions."}}, body.Messages...)
+	}
+
+	header := http.Header{}
+	header.Set("x-api-key", p.cnf.ApiKey)
+	header.Set("anthropic-version", p.cnf.Version)
+
+	out := anthropicResponse{}
+	if err := postJSON(ctx, p.httpClient, p.url, header, body, &out); err != nil {
+		return Response{}, fmt.Errorf("%s: %w", Anthropic, err)
+	}
+
+	sb := strings.Builder{}
+	for _, c := range out.Content {
+		if c.Type == "text" {
+			sb.WriteString(c.Text)
+		}
+	}
+
+	return Response{
+		Content:          sb.String(),
+		Model:            body.Model,
+		PromptTokens:     out.Usage.InputTokens,
+		CompletionTokens: out.Usage.OutputTokens,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"context"
+
+	"go-firestore-gpt/internal/breaker"
+)
+
+type breakerProvider struct {
+	Provider
+	breaker *breaker.Breaker
+}
+
+// CircuitBreakerMiddleware stops calling a provider while it is failing. The calls fail fast with
+// breaker.ErrOpen instead, so that the clients fall back to the next target of their chain.
+func CircuitBreakerMiddleware() Middleware {
+	return func(p Provider) Provider {
+		return &breakerProvider{Provider: p, breaker: breaker.LLM(p.Name())}
+	}
+}
+
+func (p *breakerProvider) Complete(ctx context.Context, req Request) (Response, error) {
+	var resp Response
+	err := p.breaker.Do(func() error {
+		var err error
+		resp, err = p.Provider.Complete(ctx, req)
+		return err
+	})
+	return resp, err
+}
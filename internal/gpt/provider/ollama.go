@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-firestore-gpt/internal/config"
+)
+
+// ollama talks to a local Ollama compatible server.
+type ollama struct {
+	cnf        config.Ollama
+	url        string
+	httpClient *http.Client
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+func NewOllama(cnf config.Ollama, httpClient *http.Client) Provider {
+	return &ollama{
+		cnf:        cnf,
+		url:        strings.TrimSuffix(cnf.ApiUrl, "/") + "/api/chat",
+		httpClient: httpClient,
+	}
+}
+
+func (p *ollama) Name() string {
+	return Ollama
+}
+
+func (p *ollama) DefaultModel() string {
+	return p.cnf.Model
+}
+
+func (p *ollama) Complete(ctx context.Context, req Request) (Response, error) {
+	body := ollamaRequest{
+		Model: req.Model,
+		Options: ollamaOptions{
+			Temperature: req.Temperature,
+			NumPredict:  req.MaxTokens,
+		},
+	}
+	if body.Model == "" {
+		body.Model = p.DefaultModel()
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, ollamaMessage{Role: m.Role, Content: m.Content})
+	}
+
+	out := ollamaResponse{}
+	if err := postJSON(ctx, p.httpClient, p.url, nil, body, &out); err != nil {
+		return Response{}, fmt.Errorf("%s: %w", Ollama, err)
+	}
+
+	return Response{
+		Content:          out.Message.Content,
+		Model:            body.Model,
+		PromptTokens:     out.PromptEvalCount,
+		CompletionTokens: out.EvalCount,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// openAICompatible talks to any API implementing the OpenAI chat completions endpoint, e.g. OpenAI and Gilas.
+type openAICompatible struct {
+	name         string
+	url          string
+	apiKey       string
+	defaultModel string
+	httpClient   *http.Client
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature *float32        `json:"temperature,omitempty"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// NewOpenAICompatible creates a provider for the given API url. The url can either be the base url
+// of the API, e.g. https://api.openai.com/v1, or the full chat completions endpoint.
+func NewOpenAICompatible(name, apiUrl, apiKey, defaultModel string, httpClient *http.Client) Provider {
+	url := strings.TrimSuffix(apiUrl, "/")
+	if !strings.HasSuffix(url, "/chat/completions") {
+		url += "/chat/completions"
+	}
+
+	return &openAICompatible{
+		name:         name,
+		url:          url,
+		apiKey:       apiKey,
+		defaultModel: defaultModel,
+		httpClient:   httpClient,
+	}
+}
+
+func (p *openAICompatible) Name() string {
+	return p.name
+}
+
+func (p *openAICompatible) DefaultModel() string {
+	return p.defaultModel
+}
+
+func (p *openAICompatible) Complete(ctx context.Context, req Request) (Response, error) {
+	body := openAIRequest{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+	if body.Model == "" {
+		body.Model = p.DefaultModel()
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, openAIMessage{Role: m.Role, Content: m.Content})
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+p.apiKey)
+
+	out := openAIResponse{}
+	if err := postJSON(ctx, p.httpClient, p.url, header, body, &out); err != nil {
+		return Response{}, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	if len(out.Choices) == 0 {
+		return Response{}, fmt.Errorf("%s: response has no choices", p.name)
+	}
+
+	return Response{
+		Content:          out.Choices[0].Message.Content,
+		Model:            body.Model,
+		PromptTokens:     out.Usage.PromptTokens,
+		CompletionTokens: out.Usage.CompletionTokens,
+	}, nil
+}
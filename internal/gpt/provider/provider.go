@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go-firestore-gpt/internal/config"
+)
+
+const (
+	// names of the supported providers
+	Gilas     string = "gilas"
+	OpenAI    string = "openai"
+	Anthropic string = "anthropic"
+	Ollama    string = "ollama"
+
+	RoleSystem    string = "system"
+	RoleUser      string = "user"
+	RoleAssistant string = "assistant"
+)
+
+type Message struct {
+	Role    string
+	Content string
+}
+
+type Request struct {
+	// Model overrides the default model of the provider
+	Model       string
+	Messages    []Message
+	Temperature *float32
+	MaxTokens   int
+}
+
+type Response struct {
+	Content          string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Provider is a LLM backend which completes a conversation.
+type Provider interface {
+	Name() string
+	// DefaultModel is the model completing the requests which do not override it
+	DefaultModel() string
+	Complete(ctx context.Context, req Request) (Response, error)
+}
+
+// New creates the provider with the given name from the config.
+// A nil httpClient falls back to a client with the configured request timeout.
+func New(name string, cnf config.Config, httpClient *http.Client) (Provider, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cnf.LLM.RequestTimeout}
+	}
+
+	switch name {
+	case Gilas:
+		return NewOpenAICompatible(Gilas, cnf.GilasAI.ApiUrl, cnf.GilasAI.ApiKey, cnf.GilasAI.Model, httpClient), nil
+	case OpenAI:
+		return NewOpenAICompatible(OpenAI, cnf.OpenAI.ApiUrl, cnf.OpenAI.ApiKey, cnf.OpenAI.Model, httpClient), nil
+	case Anthropic:
+		return NewAnthropic(cnf.Anthropic, httpClient), nil
+	case Ollama:
+		return NewOllama(cnf.Ollama, httpClient), nil
+	}
+
+	return nil, fmt.Errorf("unknown llm provider '%s'", name)
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	gptutils "go-firestore-gpt/internal/gpt/utils"
+	"go-firestore-gpt/internal/ratelimit"
+)
+
+type rateLimitedProvider struct {
+	Provider
+	requests  *ratelimit.Limiter
+	tokens    *ratelimit.Limiter
+	tokenizer gptutils.Tokenizer
+}
+
+// RateLimitMiddleware limits the requests and the tokens per minute of every provider. Like the providers, the tokens
+// count both the prompt and the completion: the prompt tokens are waited for before the call, and the completion
+// tokens are charged once it responded, so the next calls wait for them.
+// The limiters of a provider are shared by all of its clients.
+func RateLimitMiddleware(requestsPerMinute, tokensPerMinute int, tokenizer gptutils.Tokenizer) Middleware {
+	return func(p Provider) Provider {
+		return &rateLimitedProvider{
+			Provider:  p,
+			requests:  ratelimit.New(requestsPerMinute, time.Minute),
+			tokens:    ratelimit.New(tokensPerMinute, time.Minute),
+			tokenizer: tokenizer,
+		}
+	}
+}
+
+func (p *rateLimitedProvider) Complete(ctx context.Context, req Request) (Response, error) {
+	if err := p.requests.Wait(ctx, 1); err != nil {
+		return Response{}, err
+	}
+
+	if p.tokens != nil {
+		tokens := 0
+		for _, m := range req.Messages {
+			tokens += p.tokenizer.CountTokens(m.Content)
+		}
+		if err := p.tokens.Wait(ctx, tokens); err != nil {
+			return Response{}, err
+		}
+	}
+
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if p.tokens != nil {
+		completionTokens := resp.CompletionTokens
+		// not every provider reports the usage, so estimate it
+		if completionTokens == 0 {
+			completionTokens = p.tokenizer.CountTokens(resp.Content)
+		}
+		p.tokens.Charge(completionTokens)
+	}
+	return resp, nil
+}
@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"net/http"
+	"sync"
+
+	"go-firestore-gpt/internal/config"
+)
+
+// Middleware decorates a provider, e.g. with caching.
+type Middleware func(Provider) Provider
+
+// Registry lazily creates the providers and shares them between the enrichments.
+type Registry struct {
+	cnf         config.Config
+	httpClient  *http.Client
+	middlewares []Middleware
+	providers   map[string]Provider
+	mu          sync.Mutex
+}
+
+// NewRegistry creates a registry whose providers are decorated by the middlewares.
+// The first middleware is the outermost one.
+func NewRegistry(cnf config.Config, httpClient *http.Client, middlewares ...Middleware) *Registry {
+	return &Registry{
+		cnf:         cnf,
+		httpClient:  httpClient,
+		middlewares: middlewares,
+		providers:   make(map[string]Provider),
+		mu:          sync.Mutex{},
+	}
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.providers[name]; ok {
+		return p, nil
+	}
+
+	p, err := New(name, r.cnf, r.httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		p = r.middlewares[i](p)
+	}
+	r.providers[name] = p
+	return p, nil
+}
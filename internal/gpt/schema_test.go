@@ -0,0 +1,91 @@
+package gpt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type judgement struct {
+	ID      string   `json:"id"`
+	Score   float64  `json:"score,omitempty" jsonschema:"minimum=0,maximum=1"`
+	Label   string   `json:"label,omitempty" jsonschema:"enum=positive|negative"`
+	Count   int      `json:"count,omitempty"`
+	Ignored string   `json:"-"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+type evaluation struct {
+	Videos []judgement `json:"videos"`
+	Done   bool        `json:"done,omitempty"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := SchemaOf(evaluation{})
+
+	if schema.Type != "object" || strings.Join(schema.Required, ",") != "videos" {
+		t.Errorf("got %s, want an object requiring the videos", schema)
+	}
+
+	item := schema.Properties["videos"].Items
+	if item == nil || strings.Join(item.Required, ",") != "id" {
+		t.Fatalf("got %s, want items requiring the id", schema)
+	}
+	if _, ok := item.Properties["-"]; ok {
+		t.Errorf("got %s, want the ignored field left out", item)
+	}
+
+	score := item.Properties["score"]
+	if score.Type != "number" || score.Minimum == nil || *score.Minimum != 0 || score.Maximum == nil || *score.Maximum != 1 {
+		t.Errorf("got score %s, want a number between 0 and 1", score)
+	}
+	if label := item.Properties["label"]; strings.Join(label.Enum, ",") != "positive,negative" {
+		t.Errorf("got label %s, want the enum", label)
+	}
+	if count := item.Properties["count"]; count.Type != "integer" {
+		t.Errorf("got count %s, want an integer", count)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{"valid", `{"videos": [{"id": "a", "score": 0.5, "label": "positive", "count": 2, "tags": ["x"]}]}`, ""},
+		{"no videos", `{"videos": []}`, ""},
+		{"bounds are inclusive", `{"videos": [{"id": "a", "score": 1}, {"id": "b", "score": 0}]}`, ""},
+		{"not an object", `[]`, "$: expected an object"},
+		{"missing required field", `{}`, "$: missing required field 'videos'"},
+		{"missing required item field", `{"videos": [{"score": 0.5}]}`, "$.videos[0]: missing required field 'id'"},
+		{"not an array", `{"videos": {"id": "a"}}`, "$.videos: expected an array"},
+		{"not a string", `{"videos": [{"id": 1}]}`, "$.videos[0].id: expected a string"},
+		{"below the minimum", `{"videos": [{"id": "a", "score": -0.1}]}`, "$.videos[0].score: -0.1 is less than the minimum 0"},
+		{"above the maximum", `{"videos": [{"id": "a", "score": 7}]}`, "$.videos[0].score: 7 is greater than the maximum 1"},
+		{"not in the enum", `{"videos": [{"id": "a", "label": "neutral"}]}`, "$.videos[0].label: 'neutral' is not one of positive, negative"},
+		{"not an integer", `{"videos": [{"id": "a", "count": 1.5}]}`, "$.videos[0].count: expected an integer"},
+		{"not a boolean", `{"videos": [], "done": "yes"}`, "$.done: expected a boolean"},
+	}
+
+	schema := SchemaOf(evaluation{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			err := schema.Validate(doc)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
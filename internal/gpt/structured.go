@@ -0,0 +1,206 @@
+package gpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// number of corrective prompts sent after the first invalid response
+	maxRepairAttempts = 2
+
+	REPAIR_INSTRUCTION string = `Your previous response could not be used: %s.
+	Respond again with only a valid JSON document matching this JSON schema, without any other text or code fences:
+	%s`
+)
+
+var ErrInvalidResponse = fmt.Errorf("gpt returned an invalid structured response")
+
+// Chat is the conversational surface of a gpt client used by the structured output layer.
+type Chat interface {
+	Instruct(instruction string) error
+	Prompt(ctx context.Context, prompt string) (string, error)
+}
+
+// fallbacker is implemented by chats which can restart the conversation with another model
+type fallbacker interface {
+	Fallback() bool
+}
+
+// PromptJSON sends the prompt and decodes the response into T. The response is validated against
+// the schema derived from T and, if it is invalid, the model is asked to correct it.
+func PromptJSON[T any](ctx context.Context, chat Chat, prompt string) (T, error) {
+	var zero T
+	return PromptJSONWithSchema[T](ctx, chat, prompt, SchemaOf(zero))
+}
+
+// PromptJSONWithSchema works like PromptJSON but validates the response against the given schema.
+// If the chat supports falling back to another model, it is used once the repair attempts are exhausted.
+func PromptJSONWithSchema[T any](ctx context.Context, chat Chat, prompt string, schema *Schema) (T, error) {
+	for {
+		result, err := promptJSON[T](ctx, chat, prompt, schema)
+		if err == nil || !errors.Is(err, ErrInvalidResponse) {
+			return result, err
+		}
+
+		f, ok := chat.(fallbacker)
+		if !ok || !f.Fallback() {
+			return result, err
+		}
+		log.Error().Err(err).Msg("falling back to the next model due to invalid responses")
+	}
+}
+
+func promptJSON[T any](ctx context.Context, chat Chat, prompt string, schema *Schema) (T, error) {
+	var result T
+
+	response, err := chat.Prompt(ctx, prompt)
+	if err != nil {
+		return result, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err = decodeJSON[T](response, schema)
+		if err == nil {
+			return result, nil
+		}
+
+		if attempt >= maxRepairAttempts {
+			break
+		}
+
+		log.Debug().Err(err).Msgf("invalid structured response, repair attempt %d", attempt+1)
+		response, err = chat.Prompt(ctx, fmt.Sprintf(REPAIR_INSTRUCTION, err.Error(), schema.String()))
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+}
+
+func decodeJSON[T any](response string, schema *Schema) (T, error) {
+	var result T
+
+	doc, err := ExtractJSON(response)
+	if err != nil {
+		return result, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal([]byte(doc), &generic); err != nil {
+		// models often leave a trailing comma behind the last item
+		doc = removeTrailingCommas(doc)
+		if e := json.Unmarshal([]byte(doc), &generic); e != nil {
+			return result, fmt.Errorf("malformed json: %w", err)
+		}
+	}
+
+	if err := schema.Validate(generic); err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal([]byte(doc), &result); err != nil {
+		return result, fmt.Errorf("malformed json: %w", err)
+	}
+
+	return result, nil
+}
+
+// ExtractJSON returns the first JSON object or array found in a noisy response,
+// e.g. a response wrapped in ```json fences or surrounded by explanations.
+func ExtractJSON(s string) (string, error) {
+	if i := strings.Index(s, "```"); i >= 0 {
+		fenced := s[i+3:]
+		// skip the language hint of the fence, e.g. ```json
+		if nl := strings.IndexByte(fenced, '\n'); nl >= 0 {
+			fenced = fenced[nl+1:]
+		}
+		if end := strings.Index(fenced, "```"); end >= 0 {
+			if doc, err := firstJSONValue(fenced[:end]); err == nil {
+				return doc, nil
+			}
+		}
+	}
+
+	return firstJSONValue(s)
+}
+
+// firstJSONValue scans for the first balanced {...} or [...] while ignoring brackets inside strings.
+func firstJSONValue(s string) (string, error) {
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return "", fmt.Errorf("no json found in the response")
+	}
+
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return s[start : i+1], nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("unterminated json in the response")
+}
+
+func removeTrailingCommas(s string) string {
+	sb := strings.Builder{}
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			sb.WriteByte(c)
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		}
+
+		if c == ',' {
+			next := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if strings.HasPrefix(next, "}") || strings.HasPrefix(next, "]") {
+				continue
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,200 @@
+package gpt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"bare object", `{"ids": ["a"]}`, `{"ids": ["a"]}`},
+		{"bare array", `["a", "b"]`, `["a", "b"]`},
+		{"fenced with a language hint", "```json\n{\"ids\": [\"a\"]}\n```", `{"ids": ["a"]}`},
+		{"fenced without a language hint", "```\n{\"ids\": []}\n```", `{"ids": []}`},
+		{"surrounded by explanations", "Here are the videos: {\"ids\": [\"a\"]} Hope it helps!", `{"ids": ["a"]}`},
+		{"text before the fence", "Sure!\n```json\n{\"queries\": [\"kindle\"]}\n```\nAnything else?", `{"queries": ["kindle"]}`},
+		{"brackets inside strings", `{"reason": "a {curly} [square] one"} trailing`, `{"reason": "a {curly} [square] one"}`},
+		{"escaped quotes inside strings", `{"reason": "the \"best\" }"}`, `{"reason": "the \"best\" }"}`},
+		{"first of several values", `{"a": 1} {"b": 2}`, `{"a": 1}`},
+		{"invalid fence content falls back to the text", "```json\nnot json\n``` {\"a\": 1}", `{"a": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractJSON(tt.response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractJSONErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"no json", "I could not find any relevant video."},
+		{"unterminated", `{"ids": ["a"`},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ExtractJSON(tt.response); err == nil {
+				t.Errorf("got %q, want an error", got)
+			}
+		})
+	}
+}
+
+type ids struct {
+	IDs []string `json:"ids"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     []string
+		wantErr  bool
+	}{
+		{"valid", `{"ids": ["a", "b"]}`, []string{"a", "b"}, false},
+		{"trailing commas", "```json\n{\"ids\": [\"a\", \"b\",],}\n```", []string{"a", "b"}, false},
+		{"comma inside a string is kept", `{"ids": ["a,]", "b",]}`, []string{"a,]", "b"}, false},
+		{"missing required field", `{"videos": []}`, nil, true},
+		{"wrong type", `{"ids": "a"}`, nil, true},
+		{"malformed", `{"ids": ["a" "b"]}`, nil, true},
+	}
+
+	schema := SchemaOf(ids{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeJSON[ids](tt.response, schema)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got.IDs, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got.IDs, tt.want)
+			}
+		})
+	}
+}
+
+// fakeChat answers the prompts with its responses in order, and records the prompts
+type fakeChat struct {
+	responses []string
+	prompts   []string
+	fallbacks int
+	// the number of fallbacks allowed, each one answers with the fallback responses
+	targets           int
+	fallbackResponses []string
+}
+
+func (c *fakeChat) Instruct(string) error {
+	return nil
+}
+
+func (c *fakeChat) Prompt(_ context.Context, prompt string) (string, error) {
+	c.prompts = append(c.prompts, prompt)
+	if len(c.responses) == 0 {
+		return "", errors.New("no response left")
+	}
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+func (c *fakeChat) Fallback() bool {
+	if c.fallbacks >= c.targets {
+		return false
+	}
+	c.fallbacks++
+	c.responses = append([]string{}, c.fallbackResponses...)
+	return true
+}
+
+func TestPromptJSONRepair(t *testing.T) {
+	tests := []struct {
+		name        string
+		responses   []string
+		want        []string
+		wantPrompts int
+		wantErr     error
+	}{
+		{"valid at once", []string{`{"ids": ["a"]}`}, []string{"a"}, 1, nil},
+		{"repaired once", []string{`{}`, `{"ids": ["a"]}`}, []string{"a"}, 2, nil},
+		{"repaired twice", []string{`none`, `{"ids": 1}`, `{"ids": ["a"]}`}, []string{"a"}, 3, nil},
+		{"repairs exhausted", []string{`{}`, `{}`, `{}`, `{"ids": ["a"]}`}, nil, 1 + maxRepairAttempts, ErrInvalidResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := &fakeChat{responses: tt.responses}
+			got, err := PromptJSON[ids](context.Background(), chat, "Product name: 'Kindle'")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if strings.Join(got.IDs, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got.IDs, tt.want)
+			}
+			if len(chat.prompts) != tt.wantPrompts {
+				t.Errorf("got %d prompts, want %d", len(chat.prompts), tt.wantPrompts)
+			}
+		})
+	}
+}
+
+func TestPromptJSONRepairPrompt(t *testing.T) {
+	chat := &fakeChat{responses: []string{`{}`, `{"ids": []}`}}
+	if _, err := PromptJSON[ids](context.Background(), chat, "Product name: 'Kindle'"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the repair prompt tells the model what was wrong and which schema to follow
+	repair := chat.prompts[1]
+	if !strings.Contains(repair, "missing required field 'ids'") || !strings.Contains(repair, SchemaOf(ids{}).String()) {
+		t.Errorf("unexpected repair prompt %q", repair)
+	}
+}
+
+func TestPromptJSONFallback(t *testing.T) {
+	invalid := []string{`{}`, `{}`, `{}`}
+
+	chat := &fakeChat{responses: invalid, targets: 1, fallbackResponses: []string{`{"ids": ["a"]}`}}
+	got, err := PromptJSON[ids](context.Background(), chat, "Product name: 'Kindle'")
+	if err != nil || len(got.IDs) != 1 || chat.fallbacks != 1 {
+		t.Errorf("with a fallback: got %q, %v after %d fallbacks", got.IDs, err, chat.fallbacks)
+	}
+
+	chat = &fakeChat{responses: invalid}
+	if _, err := PromptJSON[ids](context.Background(), chat, "Product name: 'Kindle'"); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("without a fallback: got %v, want %v", err, ErrInvalidResponse)
+	}
+}
+
+func TestPromptJSONError(t *testing.T) {
+	// the errors of the chat are not repaired
+	chat := &fakeChat{}
+	if _, err := PromptJSON[ids](context.Background(), chat, "Product name: 'Kindle'"); err == nil || errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("got %v, want the error of the chat", err)
+	}
+	if len(chat.prompts) != 1 {
+		t.Errorf("got %d prompts, want 1", len(chat.prompts))
+	}
+}
@@ -0,0 +1,58 @@
+package gpt
+
+import (
+	"fmt"
+	"strings"
+
+	"go-firestore-gpt/internal/gpt/provider"
+)
+
+// Target is a model served by a provider. An empty model means the default model of the provider.
+type Target struct {
+	Provider provider.Provider
+	Model    string
+}
+
+func (t Target) String() string {
+	if t.Model == "" {
+		return t.Provider.Name()
+	}
+	return t.Provider.Name() + "/" + t.Model
+}
+
+// ParseTargets parses specs formatted as 'provider/model' or 'provider', e.g. 'openai/gpt-4o'.
+func ParseTargets(specs []string, registry *provider.Registry) ([]Target, error) {
+	targets := []Target{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		name, model, _ := strings.Cut(spec, "/")
+		p, err := registry.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("parse target '%s': %w", spec, err)
+		}
+		targets = append(targets, Target{Provider: p, Model: model})
+	}
+	return targets, nil
+}
+
+// ParseTaskChains parses specs formatted as 'task=chain'. The targets of a chain are separated by '|'.
+func ParseTaskChains(specs []string, registry *provider.Registry) (map[string][]Target, error) {
+	chains := make(map[string][]Target)
+	for _, spec := range specs {
+		task, chain, found := strings.Cut(strings.TrimSpace(spec), "=")
+		if !found {
+			return nil, fmt.Errorf("task chain '%s' must be formatted as 'task=chain'", spec)
+		}
+
+		targets, err := ParseTargets(strings.Split(chain, "|"), registry)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", task, err)
+		}
+		chains[task] = targets
+	}
+	return chains, nil
+}
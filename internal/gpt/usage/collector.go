@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"context"
+	"sync"
+)
+
+type collectorKey struct{}
+
+// Collector sums up the usage of the llm calls made with a context, e.g. to compare the cost of two runs.
+type Collector struct {
+	mu               sync.Mutex
+	calls            int
+	promptTokens     int
+	completionTokens int
+	cost             float64
+}
+
+// WithCollector returns a context whose llm calls are summed up by the returned collector.
+func WithCollector(ctx context.Context) (context.Context, *Collector) {
+	c := &Collector{}
+	return context.WithValue(ctx, collectorKey{}, c), c
+}
+
+func collectorFrom(ctx context.Context) *Collector {
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+func (c *Collector) add(promptTokens, completionTokens int, cost float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	c.promptTokens += promptTokens
+	c.completionTokens += completionTokens
+	c.cost += cost
+}
+
+// Snapshot returns a copy of the collected usage.
+func (c *Collector) Snapshot() (calls, promptTokens, completionTokens int, cost float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls, c.promptTokens, c.completionTokens, c.cost
+}
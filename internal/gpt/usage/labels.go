@@ -0,0 +1,20 @@
+package usage
+
+import "context"
+
+type labelsKey struct{}
+
+// Labels attribute the llm calls made with a context to a product and an enrichment.
+type Labels struct {
+	ProductId  string
+	Enrichment string
+}
+
+func WithLabels(ctx context.Context, productId, enrichment string) context.Context {
+	return context.WithValue(ctx, labelsKey{}, Labels{ProductId: productId, Enrichment: enrichment})
+}
+
+func LabelsFrom(ctx context.Context) Labels {
+	labels, _ := ctx.Value(labelsKey{}).(Labels)
+	return labels
+}
@@ -0,0 +1,276 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/gpt/provider"
+	gptutils "go-firestore-gpt/internal/gpt/utils"
+	"go-firestore-gpt/internal/metrics"
+	"go-firestore-gpt/internal/model"
+	llmUsageRepository "go-firestore-gpt/internal/repository/llmusage"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	flushInterval       = time.Minute
+	budgetCheckInterval = time.Minute
+	unlabeled           = "unlabeled"
+)
+
+// Meter records the tokens and the estimated cost of every llm call, aggregates them per day, product,
+// enrichment and model, and periodically persists them. If a monthly budget is set, the calls are paused
+// once it is exceeded until the next month. The monthly cost is reloaded after every flush, so the cost of
+// every worker counts towards the budget, and the calls stay paused until it is loaded.
+type Meter struct {
+	repo      llmUsageRepository.IRepository
+	tokenizer gptutils.Tokenizer
+	prices    map[string]Price
+	budget    float64
+
+	mu          sync.Mutex
+	pending     map[string]*model.LLMUsage // usage not persisted yet
+	month       string
+	monthlyCost float64 // cost of the month, both persisted and pending
+	loaded      bool    // whether the persisted cost of the month was loaded
+}
+
+func NewMeter(repo llmUsageRepository.IRepository, tokenizer gptutils.Tokenizer, cnf config.LLMUsage) (*Meter, error) {
+	prices, err := ParsePrices(cnf.Prices)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Meter{
+		repo:      repo,
+		tokenizer: tokenizer,
+		prices:    prices,
+		budget:    cnf.MonthlyBudget,
+		pending:   make(map[string]*model.LLMUsage),
+		month:     time.Now().UTC().Format("2006-01"),
+	}, nil
+}
+
+// Start loads the cost of the current month and persists the recorded usage until the context is done.
+func (m *Meter) Start(ctx context.Context) error {
+	m.refresh(ctx)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the root context is already cancelled, so give the last flush its own deadline
+			flushCtx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			m.flush(flushCtx)
+			cancel()
+			return ctx.Err()
+		case <-ticker.C:
+			m.flush(ctx)
+			m.refresh(ctx)
+		}
+	}
+}
+
+// Middleware meters the calls of the decorated provider.
+func (m *Meter) Middleware() provider.Middleware {
+	return func(p provider.Provider) provider.Provider {
+		return &meteredProvider{Provider: p, meter: m}
+	}
+}
+
+// BudgetExceeded reports whether the cost of the current month exceeded the monthly budget.
+// The budget counts as exceeded until the cost of the month is loaded.
+func (m *Meter) BudgetExceeded() bool {
+	if m.budget <= 0 {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollMonth()
+	return !m.loaded || m.monthlyCost >= m.budget
+}
+
+// WaitForBudget blocks while the monthly budget is exceeded.
+func (m *Meter) WaitForBudget(ctx context.Context) error {
+	if !m.BudgetExceeded() {
+		return nil
+	}
+
+	log.Warn().Msgf("monthly llm budget of %.2f USD is exceeded, enrichments are paused", m.budget)
+	for m.BudgetExceeded() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(budgetCheckInterval):
+		}
+	}
+	return nil
+}
+
+func (m *Meter) record(ctx context.Context, req provider.Request, resp provider.Response) {
+	promptTokens, completionTokens := resp.PromptTokens, resp.CompletionTokens
+
+	// not every provider reports the usage, so estimate it
+	if promptTokens == 0 {
+		for _, msg := range req.Messages {
+			promptTokens += m.tokenizer.CountTokens(msg.Content)
+		}
+	}
+	if completionTokens == 0 {
+		completionTokens = m.tokenizer.CountTokens(resp.Content)
+	}
+
+	cost := m.priceOf(resp.Model).Cost(promptTokens, completionTokens)
+	if c := collectorFrom(ctx); c != nil {
+		c.add(promptTokens, completionTokens, cost)
+	}
+
+	labels := LabelsFrom(ctx)
+	if labels.Enrichment == "" {
+		labels.Enrichment = unlabeled
+	}
+
+	metricKey := labels.Enrichment + "/" + resp.Model
+	metrics.LLMCalls.Add(metricKey, 1)
+	metrics.LLMPromptTokens.Add(metricKey, int64(promptTokens))
+	metrics.LLMCompletionTokens.Add(metricKey, int64(completionTokens))
+	metrics.LLMCostUSD.AddFloat(metricKey, cost)
+
+	now := time.Now().UTC()
+	day := now.Format("2006-01-02")
+	key := fmt.Sprintf("%s/%s/%s/%s", day, labels.ProductId, labels.Enrichment, resp.Model)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.pending[key]
+	if !ok {
+		u = &model.LLMUsage{
+			Day:        day,
+			Month:      now.Format("2006-01"),
+			ProductId:  labels.ProductId,
+			Enrichment: labels.Enrichment,
+			Model:      resp.Model,
+		}
+		m.pending[key] = u
+	}
+	u.Calls++
+	u.PromptTokens += promptTokens
+	u.CompletionTokens += completionTokens
+	u.Cost += cost
+
+	m.rollMonth()
+	m.monthlyCost += cost
+}
+
+func (m *Meter) flush(ctx context.Context) {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[string]*model.LLMUsage)
+	m.mu.Unlock()
+
+	for key, u := range pending {
+		if err := m.repo.Increment(ctx, *u); err != nil {
+			log.Error().Err(err).Msgf("failed to persist llm usage %s", key)
+			// keep the usage, so the next flush persists it
+			m.mu.Lock()
+			m.requeue(key, u)
+			m.mu.Unlock()
+		}
+	}
+}
+
+// requeue adds the usage which failed to be persisted to the pending one. It must be called with the lock held.
+func (m *Meter) requeue(key string, u *model.LLMUsage) {
+	p, ok := m.pending[key]
+	if !ok {
+		m.pending[key] = u
+		return
+	}
+	p.Calls += u.Calls
+	p.PromptTokens += u.PromptTokens
+	p.CompletionTokens += u.CompletionTokens
+	p.Cost += u.Cost
+}
+
+// refresh reloads the cost of the month, including the cost of the other workers.
+func (m *Meter) refresh(ctx context.Context) {
+	month := time.Now().UTC().Format("2006-01")
+	stored, err := m.repo.MonthlyCost(ctx, month)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load the monthly llm cost")
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollMonth()
+	if m.month == month {
+		m.monthlyCost = stored + m.pendingCost(month)
+		m.loaded = true
+	}
+}
+
+// rollMonth resets the monthly cost when a new month starts. It must be called with the lock held.
+func (m *Meter) rollMonth() {
+	month := time.Now().UTC().Format("2006-01")
+	if month != m.month {
+		m.month = month
+		// until the next refresh, only the pending cost of the new month is known
+		m.monthlyCost = m.pendingCost(month)
+	}
+}
+
+// pendingCost must be called with the lock held.
+func (m *Meter) pendingCost(month string) float64 {
+	var cost float64
+	for _, u := range m.pending {
+		if u.Month == month {
+			cost += u.Cost
+		}
+	}
+	return cost
+}
+
+// priceOf returns the price of the model or of the longest known model name prefixing it, e.g. gpt-4o-2024-05-13.
+func (m *Meter) priceOf(modelName string) Price {
+	if price, ok := m.prices[modelName]; ok {
+		return price
+	}
+
+	best, bestLen := Price{}, 0
+	for name, price := range m.prices {
+		if strings.HasPrefix(modelName, name) && len(name) > bestLen {
+			best, bestLen = price, len(name)
+		}
+	}
+	return best
+}
+
+type meteredProvider struct {
+	provider.Provider
+	meter *Meter
+}
+
+func (p *meteredProvider) Complete(ctx context.Context, req provider.Request) (provider.Response, error) {
+	if err := p.meter.WaitForBudget(ctx); err != nil {
+		return provider.Response{}, err
+	}
+
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	p.meter.record(ctx, req, resp)
+	return resp, nil
+}
@@ -0,0 +1,55 @@
+package usage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Price is the cost in USD per one million tokens
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// defaultPrices are the list prices of the commonly used models. They can be overridden in the config.
+var defaultPrices = map[string]Price{
+	"gpt-3.5-turbo":           {Prompt: 0.5, Completion: 1.5},
+	"gpt-4o":                  {Prompt: 5, Completion: 15},
+	"gpt-4o-mini":             {Prompt: 0.15, Completion: 0.6},
+	"gpt-4-turbo":             {Prompt: 10, Completion: 30},
+	"claude-3-haiku-20240307": {Prompt: 0.25, Completion: 1.25},
+	"claude-3-5-sonnet":       {Prompt: 3, Completion: 15},
+}
+
+func (p Price) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Prompt + float64(completionTokens)*p.Completion) / 1_000_000
+}
+
+// ParsePrices merges specs formatted as 'model=prompt/completion' into the default prices.
+func ParsePrices(specs []string) (map[string]Price, error) {
+	prices := make(map[string]Price, len(defaultPrices))
+	for model, price := range defaultPrices {
+		prices[model] = price
+	}
+
+	for _, spec := range specs {
+		model, value, found := strings.Cut(strings.TrimSpace(spec), "=")
+		prompt, completion, found2 := strings.Cut(value, "/")
+		if !found || !found2 {
+			return nil, fmt.Errorf("price '%s' must be formatted as 'model=prompt/completion'", spec)
+		}
+
+		p, err := strconv.ParseFloat(prompt, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse price '%s': %w", spec, err)
+		}
+		c, err := strconv.ParseFloat(completion, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse price '%s': %w", spec, err)
+		}
+		prices[model] = Price{Prompt: p, Completion: c}
+	}
+
+	return prices, nil
+}
@@ -3,16 +3,26 @@ package relevantvideos
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"go-firestore-gpt/internal/breaker"
+	"go-firestore-gpt/internal/config"
 	"go-firestore-gpt/internal/eventpublisher"
 	"go-firestore-gpt/internal/eventpublisher/event"
-	"go-firestore-gpt/internal/handler/relevantvideos/instructor"
+	"go-firestore-gpt/internal/experiment"
+	"go-firestore-gpt/internal/gpt/usage"
+	"go-firestore-gpt/internal/jobs"
 	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/prompt"
 	relevantVideosRepository "go-firestore-gpt/internal/repository/relevantvideos"
+	"go-firestore-gpt/internal/transcript"
 	"go-firestore-gpt/internal/utils"
-	"go-firestore-gpt/internal/youtube"
+	"go-firestore-gpt/internal/videosource"
 
 	gpt "go-firestore-gpt/internal/gpt"
 
@@ -20,11 +30,69 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	// characters of the transcript sample sent along with each video
+	transcriptSampleChars = 1500
+	// videos searched in each video source, split between the search queries
+	maxSearchResults = 10
+	// videos searched per query, however many queries there are
+	minSearchResultsPerQuery = 4
+)
+
+// videoEvaluation is the structured response of the video evaluation instruction.
+// The first version of the instruction only responds with the ids, the later ones with a judgement per video,
+// which is scored since the third version.
+type videoEvaluation struct {
+	IDs    []string         `json:"ids,omitempty"`
+	Videos []videoJudgement `json:"videos,omitempty"`
+}
+
+// searchQueries is the structured response of the product name extraction instruction since its second version.
+type searchQueries struct {
+	Queries []string `json:"queries"`
+}
+
+type videoJudgement struct {
+	ID     string  `json:"id"`
+	Score  float64 `json:"score,omitempty" jsonschema:"minimum=0,maximum=1"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// videoEvaluationSchema returns the schema of the responses to the version of the video evaluation instruction,
+// so that a response without the ids, the videos or their scores is repaired instead of storing no videos.
+func videoEvaluationSchema(version string) *gpt.Schema {
+	schema := gpt.SchemaOf(videoEvaluation{})
+	switch version {
+	case prompt.FormatVersion(1):
+		schema.Required = []string{"ids"}
+	case prompt.FormatVersion(2):
+		schema.Required = []string{"videos"}
+	default:
+		schema.Required = []string{"videos"}
+		schema.Properties["videos"].Items.Required = []string{"id", "score"}
+	}
+	return schema
+}
+
+func (e videoEvaluation) judgements() []videoJudgement {
+	judgements := append([]videoJudgement{}, e.Videos...)
+	for _, id := range e.IDs {
+		judgements = append(judgements, videoJudgement{ID: id})
+	}
+	return judgements
+}
+
 type Handler struct {
 	productEventPublisher eventpublisher.Publisher
 	relevantVideosRepo    relevantVideosRepository.IRepository
 	gptFactory            gpt.ClientFactory
-	youtubeClient         youtube.YouTubeAPI
+	videoSource           videosource.Source
+	transcripts           transcript.Source
+	prompts               *prompt.Registry
+	experiment            *experiment.Experiment
+	gate                  *breaker.Gate
+	jobs                  *jobs.Tracker
+	cnf                   config.RelevantVideos
 	productSubscriptionCh event.EventChannel
 }
 
@@ -32,12 +100,24 @@ func New(
 	productEventPublisher eventpublisher.Publisher,
 	relevantVideosRepo relevantVideosRepository.IRepository,
 	gptFactory gpt.ClientFactory,
-	youtubeClient youtube.YouTubeAPI) *Handler {
+	videoSource videosource.Source,
+	transcripts transcript.Source,
+	prompts *prompt.Registry,
+	experiment *experiment.Experiment,
+	gate *breaker.Gate,
+	tracker *jobs.Tracker,
+	cnf config.RelevantVideos) *Handler {
 	return &Handler{
 		productEventPublisher: productEventPublisher,
 		relevantVideosRepo:    relevantVideosRepo,
 		gptFactory:            gptFactory,
-		youtubeClient:         youtubeClient,
+		videoSource:           videoSource,
+		transcripts:           transcripts,
+		prompts:               prompts,
+		experiment:            experiment,
+		gate:                  gate,
+		jobs:                  tracker,
+		cnf:                   cnf,
 		productSubscriptionCh: make(event.EventChannel),
 	}
 }
@@ -128,6 +208,8 @@ func (h Handler) handleProduct(ctx context.Context, product model.Product) error
 	err := h.relevantVideosRepo.CreateIfNotExist(ctx, model.RelevantVideos{
 		ProductId:   product.Id,
 		ProductName: product.Name,
+		Region:      product.Region,
+		Language:    product.Language,
 		Ready:       utils.BoolToPointer(false),
 	})
 	if err != nil {
@@ -136,14 +218,52 @@ func (h Handler) handleProduct(ctx context.Context, product model.Product) error
 	return err
 }
 
-func (h *Handler) handleVideos(ctx context.Context, relevantVideo model.RelevantVideos) error {
+func (h *Handler) handleVideos(ctx context.Context, relevantVideo model.RelevantVideos) (err error) {
+	queued, jobErr := h.jobs.Queued(ctx, *relevantVideo.ProductId, model.EnrichmentRelevantVideos)
+	if errors.Is(jobErr, jobs.ErrCanceled) {
+		log.Info().Msgf("video search is canceled - productId %s", *relevantVideo.ProductId)
+		return nil
+	}
+	if jobErr != nil {
+		log.Error().Err(jobErr).Msgf("failed to get the queued video search of productId %s", *relevantVideo.ProductId)
+	}
 
-	suggestedVideos, err := h.searchYoutube(ctx, relevantVideo)
-	if err != nil {
+	// pause while the dependencies of the search are down
+	if err := h.gate.Wait(ctx); err != nil {
 		return err
 	}
 
-	relevantVideos, err := h.evaluateSuggestedVideos(ctx, relevantVideo, suggestedVideos)
+	ctx, finish := h.jobs.Begin(ctx, *relevantVideo.ProductId, model.EnrichmentRelevantVideos, queued)
+	defer func() { finish(err) }()
+
+	ctx = usage.WithLabels(ctx, *relevantVideo.ProductId, model.EnrichmentRelevantVideos)
+
+	control := experiment.Control(h.gptFactory)
+	var relevantVideos []model.Video
+	var promptVersions map[string]string
+	var candidates []videosource.Video
+	var searchPromptVersion string
+	searched := false
+	find := func(ctx context.Context) (experiment.Outcome, error) {
+		var err error
+		candidates, searchPromptVersion, err = h.searchCandidates(ctx, relevantVideo, control)
+		if err != nil {
+			return videosOutcome(nil, nil), err
+		}
+		searched = true
+		relevantVideos, promptVersions, err = h.evaluateCandidates(ctx, relevantVideo, candidates, searchPromptVersion, control)
+		return videosOutcome(relevantVideos, promptVersions), err
+	}
+
+	if h.experiment.Applies(model.EnrichmentRelevantVideos, *relevantVideo.ProductId) {
+		err = h.experiment.Observe(ctx, *relevantVideo.ProductId, control, find)
+		if searched {
+			h.runTreatment(ctx, relevantVideo, candidates, searchPromptVersion)
+		}
+	} else {
+		_, err = find(ctx)
+	}
+
 	if err != nil {
 		return err
 	}
@@ -154,6 +274,8 @@ func (h *Handler) handleVideos(ctx context.Context, relevantVideo model.Relevant
 
 	relevantVideo.Videos = relevantVideos
 	relevantVideo.Ready = utils.BoolToPointer(true)
+	relevantVideo.SearchedAt = time.Now().UTC()
+	relevantVideo.PromptVersions = promptVersions
 	err = h.relevantVideosRepo.Update(ctx, relevantVideo)
 
 	if err != nil {
@@ -163,66 +285,290 @@ func (h *Handler) handleVideos(ctx context.Context, relevantVideo model.Relevant
 	return err
 }
 
-func (h *Handler) searchYoutube(ctx context.Context, relevantVideo model.RelevantVideos) ([]youtube.Video, error) {
-	suggestedVideos := []youtube.Video{}
+// runTreatment evaluates the candidate videos searched by the control with the alternative variant of the experiment,
+// so the treatment only varies the evaluation and does not spend the search quota again.
+// Its outcome is only recorded by the experiment and never persisted as the product's videos.
+func (h *Handler) runTreatment(ctx context.Context, relevantVideo model.RelevantVideos, candidates []videosource.Video, searchPromptVersion string) {
+	treatment := h.experiment.Treatment(h.gptFactory)
+	h.experiment.Observe(ctx, *relevantVideo.ProductId, treatment, func(ctx context.Context) (experiment.Outcome, error) {
+		videos, promptVersions, err := h.evaluateCandidates(ctx, relevantVideo, candidates, searchPromptVersion, treatment)
+		return videosOutcome(videos, promptVersions), err
+	})
+}
 
-	gptClient, err := h.gptFactory.Client()
+// searchCandidates returns the suggested videos which were not demoted along with the version of the prompt that created the search queries
+func (h *Handler) searchCandidates(ctx context.Context, relevantVideo model.RelevantVideos, variant experiment.Variant) ([]videosource.Video, string, error) {
+	suggestedVideos, searchPromptVersion, err := h.searchVideos(ctx, relevantVideo, variant)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	return h.withoutDemoted(ctx, *relevantVideo.ProductId, suggestedVideos), searchPromptVersion, nil
+}
+
+// evaluateCandidates returns the relevant videos among the candidates along with the versions of the prompts that found them
+func (h *Handler) evaluateCandidates(ctx context.Context, relevantVideo model.RelevantVideos, candidates []videosource.Video, searchPromptVersion string, variant experiment.Variant) ([]model.Video, map[string]string, error) {
+	if len(candidates) == 0 {
+		return []model.Video{}, map[string]string{prompt.ProductNameExtraction: searchPromptVersion}, nil
+	}
+
+	relevantVideos, evaluationPromptVersion, err := h.evaluateSuggestedVideos(ctx, relevantVideo, candidates, variant)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return relevantVideos, map[string]string{
+		prompt.ProductNameExtraction: searchPromptVersion,
+		prompt.VideoEvaluation:       evaluationPromptVersion,
+	}, nil
+}
+
+// withoutDemoted drops the suggested videos which were demoted for their poor feedback,
+// so that searching the videos of a product again never suggests them.
+func (h *Handler) withoutDemoted(ctx context.Context, productId string, suggestedVideos []videosource.Video) []videosource.Video {
+	stored, err := h.relevantVideosRepo.GetVideos(ctx, productId)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to get the demoted videos of productId %s", productId)
+		return suggestedVideos
+	}
+
+	demoted := map[string]bool{}
+	for _, video := range stored {
+		if video.Demoted {
+			demoted[videosource.CanonicalURL(video.Url)] = true
+		}
+	}
+	if len(demoted) == 0 {
+		return suggestedVideos
+	}
+
+	videos := make([]videosource.Video, 0, len(suggestedVideos))
+	for _, video := range suggestedVideos {
+		if !demoted[videosource.CanonicalURL(video.URL)] {
+			videos = append(videos, video)
+		}
+	}
+	return videos
+}
+
+// EvaluateVideos selects the videos relevant to the product among the candidates, without persisting them.
+func (h *Handler) EvaluateVideos(ctx context.Context, relevantVideo model.RelevantVideos, candidates []videosource.Video) ([]model.Video, error) {
+	videos, _, err := h.evaluateSuggestedVideos(ctx, relevantVideo, candidates, experiment.Control(h.gptFactory))
+	return videos, err
+}
+
+func videosOutcome(videos []model.Video, promptVersions map[string]string) experiment.Outcome {
+	urls := make([]string, 0, len(videos))
+	for _, v := range videos {
+		urls = append(urls, v.Url)
+	}
+
+	return experiment.Outcome{
+		Items:          urls,
+		Output:         urls,
+		PromptVersions: promptVersions,
+	}
+}
+
+// searchVideos returns the suggested videos of every source and search query along with the version of the prompt that created the queries
+func (h *Handler) searchVideos(ctx context.Context, relevantVideo model.RelevantVideos, variant experiment.Variant) ([]videosource.Video, string, error) {
+	suggestedVideos := []videosource.Video{}
+
+	instruction, err := variant.Render(h.prompts, prompt.ProductNameExtraction, map[string]interface{}{
+		"Language":   utils.StringFromPointer(relevantVideo.Language),
+		"MaxQueries": h.maxQueries(),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	gptClient, err := variant.GptFactory.ClientForTask(gpt.TaskProductNameExtraction)
+	if err != nil {
+		return nil, "", err
 	}
 
-	gptClient.Instruct(instructor.GetProductNameExtractionInstruction())
-	productName, err := gptClient.Prompt(ctx, *relevantVideo.ProductName)
+	gptClient.Instruct(instruction.Text)
+	queries, err := h.searchQueries(ctx, gptClient, instruction.Version, *relevantVideo.ProductName)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to create search term for YouTube")
-		return suggestedVideos, err
+		log.Error().Err(err).Msg("failed to create search terms for the videos")
+		return suggestedVideos, "", err
 	}
 
-	searchTerm := fmt.Sprintf("%s", productName)
-	suggestedVideos, err = h.youtubeClient.Search(searchTerm, 10)
+	suggestedVideos, err = h.searchEach(ctx, relevantVideo, queries)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to call YouTube")
+		log.Error().Err(err).Msg("failed to search the videos")
+		return suggestedVideos, instruction.Version, err
 	}
-	return suggestedVideos, err
+	return suggestedVideos, instruction.Version, nil
 }
 
-func (h *Handler) evaluateSuggestedVideos(ctx context.Context, relevantVideo model.RelevantVideos, suggestedVideos []youtube.Video) ([]model.Video, error) {
+// searchQueries asks for the query variants of the product name, e.g. brand+model, review and how to use.
+// The first version of the instruction responds with a single search term instead of a JSON object.
+func (h *Handler) searchQueries(ctx context.Context, gptClient gpt.Chat, version string, productName string) ([]string, error) {
+	if version == prompt.FormatVersion(1) {
+		term, err := gptClient.Prompt(ctx, productName)
+		if err != nil {
+			return nil, err
+		}
+		return distinctQueries([]string{term}, 1), nil
+	}
+
+	response, err := gpt.PromptJSON[searchQueries](ctx, gptClient, productName)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := distinctQueries(response.Queries, h.maxQueries())
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no search query for the product: %s", productName)
+	}
+	return queries, nil
+}
+
+// searchEach searches the videos of each query concurrently and merges them, keeping the query which found each video first.
+// It only fails if every search failed.
+func (h *Handler) searchEach(ctx context.Context, relevantVideo model.RelevantVideos, queries []string) ([]videosource.Video, error) {
+	perQuery := max(minSearchResultsPerQuery, maxSearchResults/len(queries))
+
+	results := make([][]videosource.Video, len(queries))
+	errs := make([]error, len(queries))
+	wg := sync.WaitGroup{}
+	for i, query := range queries {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+			videos, err := h.videoSource.Search(ctx, videosource.Query{
+				ProductId:   *relevantVideo.ProductId,
+				ProductName: *relevantVideo.ProductName,
+				Term:        query,
+				Region:      utils.StringFromPointer(relevantVideo.Region),
+				Language:    utils.StringFromPointer(relevantVideo.Language),
+				MaxResults:  int64(perQuery),
+			})
+			if err != nil {
+				log.Warn().Err(err).Msgf("failed to search the videos of the query '%s'", query)
+				errs[i] = fmt.Errorf("search videos: %w, query: %s", err, query)
+				return
+			}
+			for j := range videos {
+				videos[j].Query = query
+			}
+			results[i] = videos
+		}(i, query)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(queries) {
+		return nil, errors.Join(errs...)
+	}
+	return videosource.Dedup(results...), nil
+}
+
+func (h *Handler) maxQueries() int {
+	return max(1, h.cnf.MaxQueries)
+}
+
+// distinctQueries trims the queries and drops the empty and duplicated ones, ignoring the case and the spacing
+func distinctQueries(queries []string, limit int) []string {
+	seen := map[string]bool{}
+	distinct := []string{}
+	for _, query := range queries {
+		query = strings.Join(strings.Fields(strings.Trim(query, " '\"")), " ")
+		key := strings.ToLower(query)
+		if query == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		distinct = append(distinct, query)
+		if len(distinct) == limit {
+			break
+		}
+	}
+	return distinct
+}
+
+// evaluateSuggestedVideos returns the relevant videos along with the version of the prompt that selected them
+func (h *Handler) evaluateSuggestedVideos(ctx context.Context, relevantVideo model.RelevantVideos, suggestedVideos []videosource.Video, variant experiment.Variant) ([]model.Video, string, error) {
 
 	selectedVideos := []model.Video{}
-	suggestedVideosAsJson, err := suggestedVideosToJson(suggestedVideos)
+	suggestedVideosAsJson, err := suggestedVideosToJson(suggestedVideos, h.sampleTranscripts(ctx, suggestedVideos))
 	if err != nil {
 		log.Error().Err(err).Msg("failed to conver suggested videos to json")
-		return selectedVideos, err
+		return selectedVideos, "", err
 	}
 
-	gptClient, err := h.gptFactory.Client()
+	instruction, err := variant.Render(h.prompts, prompt.VideoEvaluation, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	gptClient, err := variant.GptFactory.ClientForTask(gpt.TaskVideoEvaluation)
+	if err != nil {
+		return nil, "", err
 	}
 
 	// Use the full product name since it includes more details about the product
-	gptClient.Instruct(instructor.GetVideoEvaluationInstruction())
-	prompt := fmt.Sprintf("Product name: '%s'\nVideos: '%s'", *relevantVideo.ProductName, suggestedVideosAsJson)
-	relatedVideoIDs, err := gptClient.Prompt(ctx, prompt)
+	gptClient.Instruct(instruction.Text)
+	evaluationPrompt := fmt.Sprintf("Product name: '%s'\nVideos: '%s'", *relevantVideo.ProductName, suggestedVideosAsJson)
+	evaluation, err := gpt.PromptJSONWithSchema[videoEvaluation](ctx, gptClient, evaluationPrompt, videoEvaluationSchema(instruction.Version))
 	if err != nil {
 		log.Error().Err(err).Msg("failed to evaluate suggested videos")
-		return selectedVideos, err
+		return selectedVideos, "", err
 	}
 
-	relevantVideos := filterSuggestedVideos(relatedVideoIDs, suggestedVideos)
+	relevantVideos := rankSuggestedVideos(evaluation.judgements(), suggestedVideos, h.cnf.TopK)
 	if len(relevantVideos) == 0 {
 		log.Debug().Msgf("Could not find any relevant video for productId %s", *relevantVideo.ProductId)
 	}
 
-	return relevantVideos, err
+	return relevantVideos, instruction.Version, err
+}
+
+// sampleTranscripts returns the transcript samples of the videos keyed by their key.
+// The transcripts are optional, so the videos without one are judged by their title and description.
+func (h *Handler) sampleTranscripts(ctx context.Context, videos []videosource.Video) map[string]string {
+	samples := map[string]string{}
+	if h.transcripts == nil {
+		return samples
+	}
+
+	mu := sync.Mutex{}
+	group, gctx := errgroup.WithContext(ctx)
+	group.SetLimit(4)
+	for _, video := range videos {
+		video := video
+		group.Go(func() error {
+			text, err := h.transcripts.Transcript(gctx, video.Key())
+			if err != nil {
+				if !errors.Is(err, transcript.ErrNotFound) {
+					log.Error().Err(err).Msgf("failed to get the transcript of video %s", video.Key())
+				}
+				return nil
+			}
+
+			mu.Lock()
+			samples[video.Key()] = transcript.Sample(text, transcriptSampleChars)
+			mu.Unlock()
+			return nil
+		})
+	}
+	group.Wait()
+
+	return samples
 }
 
-func suggestedVideosToJson(videos []youtube.Video) (string, error) {
+func suggestedVideosToJson(videos []videosource.Video, transcripts map[string]string) (string, error) {
 
 	type videoFormat struct {
 		Id          string `json:"id"`
 		Title       string `json:"title"`
 		Description string `json:"description"`
+		Transcript  string `json:"transcript,omitempty"`
 	}
 
 	first500Chars := func(s string) string {
@@ -240,33 +586,77 @@ func suggestedVideosToJson(videos []youtube.Video) (string, error) {
 	vs := []videoFormat{}
 	for _, item := range videos {
 		vs = append(vs,
-			videoFormat{item.ID,
+			videoFormat{item.Key(),
 				item.Title,
-				first500Chars(item.Description)})
+				first500Chars(item.Description),
+				transcripts[item.Key()]})
 	}
 
 	b, err := json.Marshal(vs)
 	if err != nil {
-		log.Error().Err(err).Msgf("suggested videos to json %s", videos)
+		log.Error().Err(err).Msgf("suggested videos to json %v", videos)
 		return "", err
 	}
 
 	return string(b), nil
 }
 
-// Return the suggested videos that are included in the relatedVideoIDs
-func filterSuggestedVideos(relatedVideoIDs string, suggestedVideos []youtube.Video) []model.Video {
-	videos := []model.Video{}
-	// The instruction suggest that the relatedVideoIDs are comma separated
-	relatedVideoIDsList := strings.Split(relatedVideoIDs, ",")
+// Return at most topK of the suggested videos that are judged relevant, best scored first, along with
+// the score and the reason of the judgement. The unscored judgements keep the order of the response.
+// A zero topK returns all of them.
+func rankSuggestedVideos(judgements []videoJudgement, suggestedVideos []videosource.Video, topK int) []model.Video {
+	suggested := make(map[string]videosource.Video, len(suggestedVideos))
+	for _, video := range suggestedVideos {
+		suggested[video.Key()] = video
+	}
 
-	for _, videoId := range relatedVideoIDsList {
-		for _, suggested := range suggestedVideos {
-			if videoId == suggested.ID {
-				videos = append(videos, model.Video{Url: suggested.URL})
-			}
+	judged := map[string]bool{}
+	relevant := []videoJudgement{}
+	for _, judgement := range judgements {
+		if _, ok := suggested[judgement.ID]; !ok || judged[judgement.ID] {
+			continue
 		}
+		judged[judgement.ID] = true
+		relevant = append(relevant, judgement)
+	}
+
+	sort.SliceStable(relevant, func(i, j int) bool {
+		return relevant[i].Score > relevant[j].Score
+	})
+	if topK > 0 && len(relevant) > topK {
+		relevant = relevant[:topK]
+	}
+
+	videos := make([]model.Video, 0, len(relevant))
+	for i, judgement := range relevant {
+		video := toModelVideo(suggested[judgement.ID])
+		video.Rank = i + 1
+		video.Score = judgement.Score
+		video.Rationale = judgement.Reason
+		videos = append(videos, video)
 	}
 
 	return videos
 }
+
+func toModelVideo(video videosource.Video) model.Video {
+	v := model.Video{
+		Url:      video.URL,
+		Title:    video.Title,
+		Source:   video.Source,
+		SourceId: video.ID,
+		Query:    video.Query,
+	}
+
+	if d := video.Details; d != nil {
+		v.ChannelId = d.ChannelId
+		v.ChannelTitle = d.ChannelTitle
+		v.DurationSeconds = int64(d.Duration.Seconds())
+		v.ViewCount = d.ViewCount
+		v.LikeCount = d.LikeCount
+		v.PublishedAt = d.PublishedAt
+		v.Language = d.Language
+		v.ThumbnailUrl = d.ThumbnailUrl
+	}
+	return v
+}
@@ -1,9 +0,0 @@
-package instructor
-
-func GetProductNameExtractionInstruction() string {
-	return PRODUCT_NAME_EXTRACTION_INSTRUCTION
-}
-
-func GetVideoEvaluationInstruction() string {
-	return VIDEO_EVALUATION_INSTRUCTION
-}
@@ -2,16 +2,21 @@ package reviewsentiment
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
 
+	"go-firestore-gpt/internal/breaker"
 	"go-firestore-gpt/internal/eventpublisher"
 	"go-firestore-gpt/internal/eventpublisher/event"
+	"go-firestore-gpt/internal/experiment"
+	"go-firestore-gpt/internal/gpt/usage"
 	gptutils "go-firestore-gpt/internal/gpt/utils"
+	"go-firestore-gpt/internal/jobs"
 	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/prompt"
 	productRepository "go-firestore-gpt/internal/repository/product"
 	sentimentRepository "go-firestore-gpt/internal/repository/reviewsentiments"
 	"go-firestore-gpt/internal/utils"
@@ -27,6 +32,10 @@ type Handler struct {
 	sentimentRepo         sentimentRepository.IRepository
 	gptFactory            gpt.ClientFactory
 	tokenizer             gptutils.Tokenizer
+	prompts               *prompt.Registry
+	experiment            *experiment.Experiment
+	gate                  *breaker.Gate
+	jobs                  *jobs.Tracker
 	productSubscriptionCh event.EventChannel
 }
 
@@ -35,7 +44,11 @@ func New(
 	productRepo productRepository.IRepository,
 	sentimentRepo sentimentRepository.IRepository,
 	gptFactory gpt.ClientFactory,
-	tokenizer gptutils.Tokenizer) *Handler {
+	tokenizer gptutils.Tokenizer,
+	prompts *prompt.Registry,
+	experiment *experiment.Experiment,
+	gate *breaker.Gate,
+	tracker *jobs.Tracker) *Handler {
 
 	return &Handler{
 		productEventPublisher: productEventPublisher,
@@ -43,6 +56,10 @@ func New(
 		sentimentRepo:         sentimentRepo,
 		gptFactory:            gptFactory,
 		tokenizer:             tokenizer,
+		prompts:               prompts,
+		experiment:            experiment,
+		gate:                  gate,
+		jobs:                  tracker,
 		productSubscriptionCh: make(event.EventChannel),
 	}
 }
@@ -88,29 +105,62 @@ func (h *Handler) EventHandler(ctx context.Context) error {
 	}
 }
 
-func (h *Handler) handle(ctx context.Context, product model.Product) error {
+func (h *Handler) handle(ctx context.Context, product model.Product) (err error) {
 
-	// if sentiment analysis is already done, skip
+	queued, jobErr := h.jobs.Queued(ctx, *product.Id, model.EnrichmentReviewSentiments)
+	if errors.Is(jobErr, jobs.ErrCanceled) {
+		log.Info().Msgf("sentiment analysis is canceled - productId %s", *product.Id)
+		return nil
+	}
+	if jobErr != nil {
+		log.Error().Err(jobErr).Msgf("failed to get the queued sentiment analysis of productId %s", *product.Id)
+	}
+
+	// if sentiment analysis is already done, skip unless an admin requested it again
 	s, _ := h.sentimentRepo.GetById(ctx, *product.Id)
-	if s != nil {
+	if s != nil && queued == nil {
 		log.Debug().Msgf("sentiment is already analyzed - productId %s", *product.Id)
 		return nil
 	}
 
+	// pause while the dependencies of the analysis are down
+	if err := h.gate.Wait(ctx); err != nil {
+		return err
+	}
+
+	ctx, finish := h.jobs.Begin(ctx, *product.Id, model.EnrichmentReviewSentiments, queued)
+	defer func() { finish(err) }()
+
 	log.Debug().Msgf("sentiment analysis - productId %s", *product.Id)
-	sentimentScores, err := h.generateSentimentScores(ctx, product)
+	ctx = usage.WithLabels(ctx, *product.Id, model.EnrichmentReviewSentiments)
+
+	control := experiment.Control(h.gptFactory)
+	var top5Sentiments []model.Sentiment
+	var promptVersion string
+	analyze := func(ctx context.Context) (experiment.Outcome, error) {
+		var err error
+		top5Sentiments, promptVersion, err = h.analyze(ctx, product, control)
+		return sentimentsOutcome(top5Sentiments, promptVersion), err
+	}
+
+	if h.experiment.Applies(model.EnrichmentReviewSentiments, *product.Id) {
+		err = h.experiment.Observe(ctx, *product.Id, control, analyze)
+		h.runTreatment(ctx, product)
+	} else {
+		_, err = analyze(ctx)
+	}
+
 	if err != nil {
 		log.Error().Err(err).Msgf("review sentiment handler: failed to generate sentiments for %s", *product.Id)
 		return err
 	}
 
-	top5Sentiments := selectTop5FrequentlyMentionedSentiments(sentimentScores)
-
 	if err := h.sentimentRepo.Create(ctx, model.ReviewSentiments{
-		ProductId:  product.Id,
-		Sentiments: top5Sentiments,
-		CreatedAt:  time.Now().UTC(),
-		UpdatedAt:  time.Now().UTC(),
+		ProductId:     product.Id,
+		Sentiments:    top5Sentiments,
+		PromptVersion: promptVersion,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
 	}); err != nil {
 		log.Error().Err(err).Msgf("review sentiment handler: failed to persist %s", *product.Id)
 		return err
@@ -122,25 +172,64 @@ func (h *Handler) handle(ctx context.Context, product model.Product) error {
 	return nil
 }
 
-func (h *Handler) generateSentimentScores(ctx context.Context, product model.Product) ([]sentimentScore, error) {
+// runTreatment analyzes the product with the alternative variant of the experiment.
+// Its outcome is only recorded by the experiment and never persisted as the product's sentiments.
+func (h *Handler) runTreatment(ctx context.Context, product model.Product) {
+	treatment := h.experiment.Treatment(h.gptFactory)
+	h.experiment.Observe(ctx, *product.Id, treatment, func(ctx context.Context) (experiment.Outcome, error) {
+		sentiments, promptVersion, err := h.analyze(ctx, product, treatment)
+		return sentimentsOutcome(sentiments, promptVersion), err
+	})
+}
 
-	callGPT := func(ctx context.Context, instruction string) (string, error) {
-		gptClient, err := h.gptFactory.Client()
+// Analyze returns the top 5 sentiments of the product's reviews along with the prompt version, without persisting them.
+func (h *Handler) Analyze(ctx context.Context, product model.Product) ([]model.Sentiment, string, error) {
+	return h.analyze(ctx, product, experiment.Control(h.gptFactory))
+}
 
-		if err != nil {
-			return "", err
-		}
+// analyze returns the top 5 sentiments along with the version of the prompt that generated them
+func (h *Handler) analyze(ctx context.Context, product model.Product, variant experiment.Variant) ([]model.Sentiment, string, error) {
+	sentimentScores, promptVersion, err := h.generateSentimentScores(ctx, product, variant)
+	if err != nil {
+		return nil, "", err
+	}
 
-		gptClient.Instruct(instruction)
-		return gptClient.Prompt(ctx, "")
+	return selectTop5FrequentlyMentionedSentiments(sentimentScores), promptVersion, nil
+}
+
+// generateSentimentScores returns the sentiment scores along with the version of the prompt that generated them
+func (h *Handler) generateSentimentScores(ctx context.Context, product model.Product, variant experiment.Variant) ([]sentimentScore, string, error) {
+
+	instruction, err := variant.Render(h.prompts, prompt.SentimentAnalysis, map[string]string{"Reviews": h.productReviews(product)})
+	if err != nil {
+		return nil, "", err
 	}
 
-	response, err := callGPT(ctx, fmt.Sprintf(SENTIMENT_ANALYSIS_INSTRUCTION, h.productReviews(product)))
+	gptClient, err := variant.GptFactory.ClientForTask(gpt.TaskSentimentAnalysis)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	gptClient.Instruct(instruction.Text)
+	data, err := gpt.PromptJSON[response](ctx, gptClient, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data.Data, instruction.Version, nil
+}
+
+func sentimentsOutcome(sentiments []model.Sentiment, promptVersion string) experiment.Outcome {
+	labels := make([]string, 0, len(sentiments))
+	for _, s := range sentiments {
+		labels = append(labels, s.Label)
 	}
 
-	return responseToSentimentScore(response)
+	return experiment.Outcome{
+		Items:          labels,
+		Output:         sentiments,
+		PromptVersions: map[string]string{prompt.SentimentAnalysis: promptVersion},
+	}
 }
 
 func (h *Handler) productReviews(product model.Product) string {
@@ -152,17 +241,6 @@ func (h *Handler) productReviews(product model.Product) string {
 	return sb.String()
 }
 
-func responseToSentimentScore(responseAsString string) ([]sentimentScore, error) {
-
-	data := response{}
-	err := json.Unmarshal([]byte(responseAsString), &data)
-	if err != nil {
-		return []sentimentScore{}, err
-	}
-
-	return data.Data, nil
-}
-
 func selectTop5FrequentlyMentionedSentiments(data []sentimentScore) []model.Sentiment {
 
 	top5 := []model.Sentiment{}
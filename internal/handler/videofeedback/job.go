@@ -0,0 +1,112 @@
+package videofeedback
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/model"
+	relevantVideosRepository "go-firestore-gpt/internal/repository/relevantvideos"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Job periodically demotes the relevant videos with poor feedback, and searches the videos of a product
+// again once the demotions leave it with too few videos.
+type Job struct {
+	relevantVideosRepo relevantVideosRepository.IRepository
+	cnf                config.VideoFeedback
+}
+
+func New(relevantVideosRepo relevantVideosRepository.IRepository, cnf config.VideoFeedback) *Job {
+	return &Job{
+		relevantVideosRepo: relevantVideosRepo,
+		cnf:                cnf,
+	}
+}
+
+// Start runs the job on every interval until the context is done.
+func (j *Job) Start(ctx context.Context) error {
+	if j.cnf.Interval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(j.cnf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Error().Err(err).Msg("video feedback job failed")
+			}
+		}
+	}
+}
+
+// Run demotes the videos with poor feedback once.
+func (j *Job) Run(ctx context.Context) error {
+	downVoted, err := j.relevantVideosRepo.DownVotedVideos(ctx, j.minDownVotes())
+	if err != nil {
+		return err
+	}
+
+	for productId, videos := range downVoted {
+		demoted := 0
+		for _, video := range videos {
+			if video.Demoted || video.Id == nil || !j.poorFeedback(video) {
+				continue
+			}
+
+			if err := j.relevantVideosRepo.Demote(ctx, productId, *video.Id); err != nil {
+				log.Error().Err(err).Msgf("failed to demote video %s of productId %s", *video.Id, productId)
+				continue
+			}
+			log.Info().Msgf("demoted video %s of productId %s, thumbs up: %d, down: %d", video.Url, productId, video.ThumbUp, video.ThumbDown)
+			demoted++
+		}
+
+		if demoted > 0 {
+			j.searchIfDegraded(ctx, productId)
+		}
+	}
+
+	return nil
+}
+
+// searchIfDegraded searches the videos of the product again if it has fewer than the minimum videos left.
+func (j *Job) searchIfDegraded(ctx context.Context, productId string) {
+	videos, err := j.relevantVideosRepo.GetVideos(ctx, productId)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to get the videos of productId %s", productId)
+		return
+	}
+
+	left := 0
+	for _, video := range videos {
+		if !video.Demoted {
+			left++
+		}
+	}
+	if left >= j.cnf.MinVideos {
+		return
+	}
+
+	log.Info().Msgf("productId %s has %d videos left, searching its videos again", productId, left)
+	if err := j.relevantVideosRepo.RequestSearch(ctx, productId); err != nil {
+		log.Error().Err(err).Msgf("failed to request a search of the videos of productId %s", productId)
+	}
+}
+
+func (j *Job) poorFeedback(video model.Video) bool {
+	votes := video.ThumbUp + video.ThumbDown
+	return votes >= j.cnf.MinVotes && votes > 0 && float64(video.ThumbDown)/float64(votes) >= j.cnf.DownRatio
+}
+
+// minDownVotes is the fewest thumbs down a video with poor feedback can have.
+func (j *Job) minDownVotes() int {
+	return max(1, int(math.Ceil(float64(j.cnf.MinVotes)*j.cnf.DownRatio)))
+}
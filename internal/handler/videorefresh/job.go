@@ -0,0 +1,144 @@
+package videorefresh
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/model"
+	relevantVideosRepository "go-firestore-gpt/internal/repository/relevantvideos"
+	"go-firestore-gpt/internal/videosource"
+
+	"github.com/rs/zerolog/log"
+)
+
+// the products due for a check are picked up every tick, at most a batch of them
+const tick = time.Hour
+
+// Job revisits the ready relevant videos. It checks that the stored videos are still available with their
+// video source, and searches the videos of a product again once any of them disappeared or they got too old.
+type Job struct {
+	relevantVideosRepo relevantVideosRepository.IRepository
+	videoSource        videosource.Source
+	cnf                config.VideoRefresh
+}
+
+func New(relevantVideosRepo relevantVideosRepository.IRepository, videoSource videosource.Source, cnf config.VideoRefresh) *Job {
+	return &Job{
+		relevantVideosRepo: relevantVideosRepo,
+		videoSource:        videoSource,
+		cnf:                cnf,
+	}
+}
+
+// Start checks the products due for a check on every tick until the context is done.
+func (j *Job) Start(ctx context.Context) error {
+	if j.cnf.Interval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(min(tick, j.cnf.Interval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				log.Error().Err(err).Msg("video refresh job failed")
+			}
+		}
+	}
+}
+
+// Run checks a batch of the products which were not checked for an interval.
+func (j *Job) Run(ctx context.Context) error {
+	now := time.Now().UTC()
+	due, err := j.relevantVideosRepo.CheckedBefore(ctx, now.Add(-j.cnf.Interval), j.cnf.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, rv := range due {
+		if rv.ProductId == nil {
+			continue
+		}
+		if err := j.refresh(ctx, rv, now); err != nil {
+			log.Error().Err(err).Msgf("failed to refresh the videos of productId %s", *rv.ProductId)
+		}
+	}
+	return nil
+}
+
+func (j *Job) refresh(ctx context.Context, rv model.RelevantVideos, now time.Time) error {
+	productId := *rv.ProductId
+
+	searchedAt := rv.SearchedAt
+	if searchedAt.IsZero() {
+		searchedAt = rv.CreatedAt
+	}
+	if j.cnf.MaxAge > 0 && now.Sub(searchedAt) > j.cnf.MaxAge {
+		log.Info().Msgf("the videos of productId %s were searched on %s, searching them again", productId, searchedAt.Format(time.DateOnly))
+		return j.relevantVideosRepo.RequestSearch(ctx, productId)
+	}
+
+	stored, err := j.relevantVideosRepo.GetVideos(ctx, productId)
+	if err != nil {
+		return err
+	}
+
+	candidates := []videosource.Video{}
+	videoIds := map[string]string{}
+	for _, video := range stored {
+		candidate := toCandidate(video)
+		if video.Demoted || video.Id == nil || candidate.ID == "" {
+			continue
+		}
+		candidates = append(candidates, candidate)
+		videoIds[candidate.Key()] = *video.Id
+	}
+
+	// an error leaves the product unchecked, so it is checked again on the next tick
+	available, err := videosource.Available(ctx, j.videoSource, candidates)
+	if err != nil {
+		return err
+	}
+
+	gone := 0
+	for key, videoId := range videoIds {
+		if ok, checked := available[key]; !checked || ok {
+			continue
+		}
+		if err := j.relevantVideosRepo.DeleteVideo(ctx, productId, videoId); err != nil {
+			return err
+		}
+		gone++
+	}
+
+	if gone > 0 {
+		log.Info().Msgf("%d videos of productId %s are not available anymore, searching its videos again", gone, productId)
+		return j.relevantVideosRepo.RequestSearch(ctx, productId)
+	}
+	return j.relevantVideosRepo.MarkChecked(ctx, productId)
+}
+
+// toCandidate returns the video as found by its source.
+func toCandidate(video model.Video) videosource.Video {
+	candidate := videosource.Video{
+		Source: video.Source,
+		ID:     video.SourceId,
+		URL:    video.Url,
+		Title:  video.Title,
+	}
+
+	// the videos stored before the sources were recorded are YouTube videos
+	if candidate.Source == "" {
+		candidate.Source = videosource.YouTube
+	}
+	if candidate.ID == "" && candidate.Source == videosource.YouTube {
+		candidate.ID, _ = strings.CutPrefix(videosource.CanonicalURL(video.Url), "youtube.com/watch?v=")
+	}
+	return candidate
+}
@@ -0,0 +1,252 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-firestore-gpt/internal/model"
+	enrichmentJobsRepository "go-firestore-gpt/internal/repository/enrichmentjobs"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrCanceled tells the handler not to run an enrichment whose queued job was canceled
+	ErrCanceled = errors.New("enrichment job canceled")
+	// ErrFinished is returned when canceling a job which already finished
+	ErrFinished = errors.New("enrichment job already finished")
+	// ErrRunning is returned when enqueuing an enrichment of a product which is running
+	ErrRunning = errors.New("enrichment of the product is running")
+)
+
+const (
+	// the finished jobs are saved after their context was canceled, so they are saved with their own timeout
+	saveTimeout = time.Second * 5
+	// the watch of the cancels is started again after this delay once it failed
+	watchRetryDelay = time.Second * 30
+)
+
+// Tracker records the runs of the enrichments as jobs and cancels them. The cancel of a job running on another
+// worker is requested on the job, and every worker watches the requested cancels of the jobs it runs with Start.
+// The history is best effort, a failure to save a job is logged and never fails the enrichment.
+// A nil tracker tracks nothing, e.g. in the evaluations.
+type Tracker struct {
+	repo    enrichmentJobsRepository.IRepository
+	running map[string]*run // keyed by the job id
+	mu      sync.Mutex
+}
+
+type run struct {
+	job      model.EnrichmentJob
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+func NewTracker(repo enrichmentJobsRepository.IRepository) *Tracker {
+	return &Tracker{
+		repo:    repo,
+		running: make(map[string]*run),
+		mu:      sync.Mutex{},
+	}
+}
+
+// Enqueue records a job of the enrichment of the product requested by an admin, which the handler continues once
+// the enrichment is triggered. The job already queued for the enrichment of the product is returned instead, if any.
+func (t *Tracker) Enqueue(ctx context.Context, productId, enrichment string) (model.EnrichmentJob, error) {
+	if t.isRunning(productId, enrichment) {
+		return model.EnrichmentJob{}, ErrRunning
+	}
+
+	latest, err := t.repo.Latest(ctx, productId, enrichment)
+	if err != nil {
+		return model.EnrichmentJob{}, err
+	}
+	if latest != nil && latest.Status == model.JobQueued {
+		return *latest, nil
+	}
+
+	return t.repo.Save(ctx, model.EnrichmentJob{
+		ProductId:  productId,
+		Enrichment: enrichment,
+		Status:     model.JobQueued,
+		Trigger:    model.JobTriggerAdmin,
+	})
+}
+
+// Queued returns the queued job of the enrichment of the product, nil if there is none. It returns ErrCanceled once
+// if the latest job was canceled before it started, so the triggered enrichment is skipped.
+func (t *Tracker) Queued(ctx context.Context, productId, enrichment string) (*model.EnrichmentJob, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	latest, err := t.repo.Latest(ctx, productId, enrichment)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+
+	switch {
+	case latest.Status == model.JobQueued:
+		return latest, nil
+	case latest.Status == model.JobCanceled && latest.StartedAt.IsZero() && !latest.Consumed:
+		// the later triggers of the enrichment are not skipped
+		latest.Consumed = true
+		if _, err := t.repo.Save(ctx, *latest); err != nil {
+			log.Error().Err(err).Msgf("failed to save the canceled job %s", latest.Id)
+		}
+		return nil, ErrCanceled
+	}
+	return nil, nil
+}
+
+// Begin records the run of the enrichment of the product, continuing the queued job if any. The returned context
+// is canceled by Cancel, on this worker or on another one, and finish must be called with the outcome of the run.
+func (t *Tracker) Begin(ctx context.Context, productId, enrichment string, queued *model.EnrichmentJob) (context.Context, func(error)) {
+	if t == nil {
+		return ctx, func(error) {}
+	}
+
+	job := model.EnrichmentJob{ProductId: productId, Enrichment: enrichment, Trigger: model.JobTriggerChange}
+	if queued != nil {
+		job = *queued
+	}
+	job.Status = model.JobRunning
+	job.StartedAt = time.Now().UTC()
+
+	// Save sets the id of the job even if it fails
+	job, err := t.repo.Save(ctx, job)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to save the job of %s of productId %s", enrichment, productId)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r := &run{job: job, cancel: cancel}
+	t.mu.Lock()
+	t.running[job.Id] = r
+	t.mu.Unlock()
+
+	return ctx, func(err error) {
+		t.finish(r, err)
+	}
+}
+
+func (t *Tracker) finish(r *run, err error) {
+	t.mu.Lock()
+	delete(t.running, r.job.Id)
+	canceled := r.canceled
+	t.mu.Unlock()
+	r.cancel()
+
+	job := r.job
+	job.FinishedAt = time.Now().UTC()
+	switch {
+	case canceled:
+		job.Status = model.JobCanceled
+	case err != nil:
+		job.Status = model.JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = model.JobSucceeded
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+	if _, err := t.repo.Save(ctx, job); err != nil {
+		log.Error().Err(err).Msgf("failed to save the finished job %s", job.Id)
+	}
+}
+
+// Cancel cancels a queued job, or a running job. The running job is saved as canceled once its enrichment returns.
+// The cancel of a job running on another worker is only requested, the returned job is still running then.
+func (t *Tracker) Cancel(ctx context.Context, id string) (model.EnrichmentJob, error) {
+	t.mu.Lock()
+	if r, ok := t.running[id]; ok {
+		r.canceled = true
+		r.cancel()
+		job := r.job
+		t.mu.Unlock()
+
+		job.Status = model.JobCanceled
+		return job, nil
+	}
+	t.mu.Unlock()
+
+	job, err := t.repo.GetById(ctx, id)
+	if err != nil {
+		return model.EnrichmentJob{}, err
+	}
+
+	switch {
+	case job.Finished():
+		return *job, ErrFinished
+	case job.Status == model.JobRunning:
+		if err := t.repo.RequestCancel(ctx, id); err != nil {
+			return *job, err
+		}
+		job.CancelRequested = true
+		return *job, nil
+	}
+
+	job.Status = model.JobCanceled
+	job.FinishedAt = time.Now().UTC()
+	return t.repo.Save(ctx, *job)
+}
+
+// Start cancels the runs of this worker whose cancel is requested on their job, until the context is done.
+func (t *Tracker) Start(ctx context.Context) error {
+	for {
+		t.repo.NotifyOnCancelRequested(ctx, t.cancelRequested)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(watchRetryDelay):
+			log.Warn().Msg("watching the cancels of the enrichment jobs again")
+		}
+	}
+}
+
+// cancelRequested cancels the run of the job whose cancel was requested, if this worker runs it
+func (t *Tracker) cancelRequested(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r, ok := t.running[id]; ok {
+		log.Info().Msgf("cancel of the job %s was requested", id)
+		r.canceled = true
+		r.cancel()
+	}
+}
+
+// Abandon fails a queued job whose enrichment could not be triggered.
+func (t *Tracker) Abandon(ctx context.Context, job model.EnrichmentJob, err error) {
+	job.Status = model.JobFailed
+	job.Error = err.Error()
+	job.FinishedAt = time.Now().UTC()
+	if _, err := t.repo.Save(ctx, job); err != nil {
+		log.Error().Err(err).Msgf("failed to save the abandoned job %s", job.Id)
+	}
+}
+
+func (t *Tracker) Get(ctx context.Context, id string) (*model.EnrichmentJob, error) {
+	return t.repo.GetById(ctx, id)
+}
+
+// History returns at most limit jobs, newest first. An empty productId returns the jobs of every product.
+func (t *Tracker) History(ctx context.Context, productId string, limit int) ([]model.EnrichmentJob, error) {
+	return t.repo.List(ctx, productId, limit)
+}
+
+func (t *Tracker) isRunning(productId, enrichment string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, r := range t.running {
+		if r.job.ProductId == productId && r.job.Enrichment == enrichment {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// The metrics are published by expvar and keyed by 'enrichment/model'
+var (
+	LLMCalls            = expvar.NewMap("llm_calls")
+	LLMPromptTokens     = expvar.NewMap("llm_prompt_tokens")
+	LLMCompletionTokens = expvar.NewMap("llm_completion_tokens")
+	LLMCostUSD          = expvar.NewMap("llm_cost_usd")
+)
+
+// The circuit breakers are keyed by their dependency, e.g. 'llm/gilas' or 'youtube'
+var (
+	CircuitBreakers     = expvar.NewMap("circuit_breakers")
+	CircuitBreakerOpens = expvar.NewMap("circuit_breaker_opens")
+)
+
+// The YouTube metrics are keyed by the api call, e.g. 'search.list'
+var (
+	YouTubeQuotaUnits  = expvar.NewMap("youtube_quota_units")
+	YouTubeSearchCache = expvar.NewMap("youtube_search_cache")
+)
+
+var (
+	healthMu     sync.Mutex
+	healthChecks = map[string]func() error{}
+)
+
+// RegisterHealthCheck adds a check to the /healthz endpoint.
+func RegisterHealthCheck(name string, check func() error) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	healthChecks[name] = check
+}
+
+// Serve exposes the metrics on /debug/vars and the health checks on /healthz until the context is done.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/healthz", serveHealth)
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info().Msgf("serving metrics on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ctx.Err()
+}
+
+// serveHealth reports the result of every check. A failing check degrades the service without failing the
+// endpoint, e.g. an open circuit breaker only pauses the enrichments depending on it.
+func serveHealth(w http.ResponseWriter, r *http.Request) {
+	healthMu.Lock()
+	checks := make(map[string]func() error, len(healthChecks))
+	for name, check := range healthChecks {
+		checks[name] = check
+	}
+	healthMu.Unlock()
+
+	status := "ok"
+	results := make(map[string]string, len(checks))
+	for name, check := range checks {
+		results[name] = "ok"
+		if err := check(); err != nil {
+			status = "degraded"
+			results[name] = err.Error()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "checks": results})
+}
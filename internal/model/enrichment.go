@@ -0,0 +1,7 @@
+package model
+
+const (
+	// names of the enrichments performed on the products
+	EnrichmentReviewSentiments string = "reviewSentiments"
+	EnrichmentRelevantVideos   string = "relevantVideos"
+)
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCanceled  JobStatus = "canceled"
+)
+
+const (
+	// what started a job, an admin request or a change of the product or of its videos
+	JobTriggerAdmin  string = "admin"
+	JobTriggerChange string = "change"
+)
+
+// EnrichmentJob is a run of an enrichment on a product, it is kept as the history of the enrichments
+type EnrichmentJob struct {
+	Id         string    `firestore:"id,omitempty"`
+	ProductId  string    `firestore:"productId,omitempty"`
+	Enrichment string    `firestore:"enrichment,omitempty"` // e.g. EnrichmentReviewSentiments
+	Status     JobStatus `firestore:"status,omitempty"`
+	Trigger    string    `firestore:"trigger,omitempty"`
+	Error      string    `firestore:"error,omitempty"`
+	// set to cancel a job running on another worker, which watches it
+	CancelRequested bool `firestore:"cancelRequested,omitempty"`
+	// set once a job canceled before it started skipped the enrichment it was queued for, so the later ones run
+	Consumed   bool      `firestore:"consumed,omitempty"`
+	CreatedAt  time.Time `firestore:"createdAt,omitempty"`
+	StartedAt  time.Time `firestore:"startedAt,omitempty"`
+	FinishedAt time.Time `firestore:"finishedAt,omitempty"`
+	UpdatedAt  time.Time `firestore:"updatedAt,omitempty"`
+}
+
+func (j EnrichmentJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCanceled
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// ExperimentResult is the outcome of an enrichment variant on a product
+type ExperimentResult struct {
+	Experiment       string            `firestore:"experiment,omitempty" json:"experiment"`
+	Enrichment       string            `firestore:"enrichment,omitempty" json:"enrichment"`
+	ProductId        string            `firestore:"productId,omitempty" json:"productId"`
+	Variant          string            `firestore:"variant,omitempty" json:"variant"`
+	Model            string            `firestore:"model,omitempty" json:"model"`
+	PromptVersions   map[string]string `firestore:"promptVersions,omitempty" json:"promptVersions"`
+	Items            []string          `firestore:"items,omitempty" json:"items"`   // comparable items of the output, e.g. labels or video urls
+	Output           string            `firestore:"output,omitempty" json:"output"` // output as json
+	OutputSize       int               `firestore:"outputSize,omitempty" json:"outputSize"`
+	Failed           bool              `firestore:"failed,omitempty" json:"failed"`
+	Error            string            `firestore:"error,omitempty" json:"error"`
+	Calls            int               `firestore:"calls,omitempty" json:"calls"`
+	PromptTokens     int               `firestore:"promptTokens,omitempty" json:"promptTokens"`
+	CompletionTokens int               `firestore:"completionTokens,omitempty" json:"completionTokens"`
+	Cost             float64           `firestore:"cost,omitempty" json:"cost"`
+	Duration         time.Duration     `firestore:"duration,omitempty" json:"duration"`
+	CreatedAt        time.Time         `firestore:"createdAt,omitempty" json:"createdAt"`
+}
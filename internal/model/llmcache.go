@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+type LLMCacheEntry struct {
+	Key       *string   `firestore:"key,omitempty"`
+	Value     string    `firestore:"value,omitempty"`
+	ExpiresAt time.Time `firestore:"expiresAt,omitempty"`
+	CreatedAt time.Time `firestore:"createdAt,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// LLMUsage aggregates the llm calls of a product's enrichment on a day and model.
+type LLMUsage struct {
+	Day              string    `firestore:"day,omitempty"`   // 2006-01-02
+	Month            string    `firestore:"month,omitempty"` // 2006-01
+	ProductId        string    `firestore:"productId,omitempty"`
+	Enrichment       string    `firestore:"enrichment,omitempty"`
+	Model            string    `firestore:"model,omitempty"`
+	Calls            int       `firestore:"calls,omitempty"`
+	PromptTokens     int       `firestore:"promptTokens,omitempty"`
+	CompletionTokens int       `firestore:"completionTokens,omitempty"`
+	Cost             float64   `firestore:"cost,omitempty"` // estimated cost in USD
+	UpdatedAt        time.Time `firestore:"updatedAt,omitempty"`
+}
@@ -6,6 +6,8 @@ type Product struct {
 	Id                    *string         `firestore:"id,omitempty"`
 	Name                  *string         `firestore:"name,omitempty"`
 	Description           *string         `firestore:"description,omitempty"`
+	Region                *string         `firestore:"region,omitempty"`   // ISO 3166-1 alpha-2 code of the market, e.g. DE
+	Language              *string         `firestore:"language,omitempty"` // ISO 639-1 code of the market, e.g. de
 	SentimentAnalized     *bool           `firestore:"sentimentAnalized,omitempty"`
 	RelatedVideosAnalized *bool           `firestore:"relatedVideosAnalized,omitempty"`
 	QAs                   []ProductQA     `firestore:"-"` // it is not a field but a collection
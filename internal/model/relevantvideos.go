@@ -3,18 +3,48 @@ package model
 import "time"
 
 type RelevantVideos struct {
-	ProductId   *string   `firestore:"productId,omitempty"`
-	ProductName *string   `firestore:"productName,omitempty"`
-	Videos      []Video   `firestore:"-"` // it is not a field but a collection
-	Ready       *bool     `firestore:"ready,omitempty"`
-	CreatedAt   time.Time `firestore:"createdAt,omitempty"`
-	UpdatedAt   time.Time `firestore:"updatedAt,omitempty"`
+	ProductId      *string           `firestore:"productId,omitempty"`
+	ProductName    *string           `firestore:"productName,omitempty"`
+	Region         *string           `firestore:"region,omitempty"`   // overrides the region of the video search
+	Language       *string           `firestore:"language,omitempty"` // overrides the language of the video search
+	Videos         []Video           `firestore:"-"`                  // it is not a field but a collection
+	Ready          *bool             `firestore:"ready,omitempty"`
+	PromptVersions map[string]string `firestore:"promptVersions,omitempty"` // keyed by the prompt name
+	SearchedAt     time.Time         `firestore:"searchedAt,omitempty"`     // when the videos were last searched
+	CreatedAt      time.Time         `firestore:"createdAt,omitempty"`
+	UpdatedAt      time.Time         `firestore:"updatedAt,omitempty"`
 }
 
 type Video struct {
-	Id        *string   `firestore:"id,omitempty"`
-	Url       string    `firestore:"url,omitempty"`
-	ThumbUp   int       `firestore:"thumbup,omitempty"`
-	ThumbDown int       `firestore:"thumbdown,omitempty"`
-	CreatedAt time.Time `firestore:"createdAt,omitempty"`
+	Id              *string   `firestore:"id,omitempty"`
+	Url             string    `firestore:"url,omitempty"`
+	Title           string    `firestore:"title,omitempty"`
+	Source          string    `firestore:"source,omitempty"`   // the video source which found it, e.g. youtube
+	SourceId        string    `firestore:"sourceId,omitempty"` // the id of the video in its source
+	Query           string    `firestore:"query,omitempty"`    // the search query which found it
+	ChannelId       string    `firestore:"channelId,omitempty"`
+	ChannelTitle    string    `firestore:"channelTitle,omitempty"`
+	DurationSeconds int64     `firestore:"durationSeconds,omitempty"`
+	ViewCount       int64     `firestore:"viewCount,omitempty"`
+	LikeCount       int64     `firestore:"likeCount,omitempty"`
+	PublishedAt     time.Time `firestore:"publishedAt,omitempty"`
+	Language        string    `firestore:"language,omitempty"`
+	ThumbnailUrl    string    `firestore:"thumbnailUrl,omitempty"`
+	Rank            int       `firestore:"rank,omitempty"`      // 1 is the best video of the product
+	Score           float64   `firestore:"score,omitempty"`     // relevance from 0 to 1 judged by the llm
+	Rationale       string    `firestore:"rationale,omitempty"` // why the video was judged relevant
+	ThumbUp         int       `firestore:"thumbup,omitempty"`
+	ThumbDown       int       `firestore:"thumbdown,omitempty"`
+	Demoted         bool      `firestore:"demoted,omitempty"` // hidden for its poor feedback and never suggested again
+	DemotedAt       time.Time `firestore:"demotedAt,omitempty"`
+	CreatedAt       time.Time `firestore:"createdAt,omitempty"`
+	UpdatedAt       time.Time `firestore:"updatedAt,omitempty"`
 }
+
+// Vote is the feedback of a user on a relevant video
+type Vote string
+
+const (
+	VoteUp   Vote = "up"
+	VoteDown Vote = "down"
+)
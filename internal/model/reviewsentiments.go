@@ -5,8 +5,10 @@ import "time"
 type ReviewSentiments struct {
 	ProductId  *string     `firestore:"productId,omitempty"`
 	Sentiments []Sentiment `firestore:"-"` // it is not a field but a collection
-	CreatedAt  time.Time   `firestore:"createdAt,omitempty"`
-	UpdatedAt  time.Time   `firestore:"updatedAt,omitempty"`
+	// version of the prompt which generated the sentiments
+	PromptVersion string    `firestore:"promptVersion,omitempty"`
+	CreatedAt     time.Time `firestore:"createdAt,omitempty"`
+	UpdatedAt     time.Time `firestore:"updatedAt,omitempty"`
 }
 
 type Sentiment struct {
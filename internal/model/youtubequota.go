@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// YouTubeQuota sums up the YouTube Data API quota units spent on a day, in Pacific time when the quota resets.
+type YouTubeQuota struct {
+	Day       string    `firestore:"day,omitempty"` // 2006-01-02
+	Units     int       `firestore:"units,omitempty"`
+	UpdatedAt time.Time `firestore:"updatedAt,omitempty"`
+}
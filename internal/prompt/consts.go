@@ -0,0 +1,10 @@
+package prompt
+
+const (
+	// names of the prompt templates
+	SentimentAnalysis     string = "sentiment-analysis"
+	ProductNameExtraction string = "product-name-extraction"
+	VideoEvaluation       string = "video-evaluation"
+
+	templateExt string = ".tmpl"
+)
@@ -0,0 +1,166 @@
+package prompt
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"go-firestore-gpt/internal/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// templates are named '<name>.v<version>.tmpl', e.g. sentiment-analysis.v2.tmpl
+var templateFileName = regexp.MustCompile(`^(.+)\.v(\d+)\.tmpl$`)
+
+// Prompt is a rendered template along with its version.
+type Prompt struct {
+	Name    string
+	Version string
+	Text    string
+}
+
+// Registry keeps the versions of each prompt template. The embedded templates can be
+// overridden, or new versions can be added, with the templates of an external directory.
+type Registry struct {
+	templates map[string]map[int]*template.Template
+	pinned    map[string]int
+	mu        sync.RWMutex
+}
+
+func New(cnf config.Prompt) (*Registry, error) {
+	r := &Registry{
+		templates: make(map[string]map[int]*template.Template),
+		pinned:    make(map[string]int),
+	}
+
+	sub, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(sub); err != nil {
+		return nil, err
+	}
+
+	if cnf.Dir != "" {
+		if err := r.load(os.DirFS(cnf.Dir)); err != nil {
+			return nil, fmt.Errorf("load prompts from %s: %w", cnf.Dir, err)
+		}
+	}
+
+	for _, spec := range cnf.Versions {
+		name, version, found := strings.Cut(strings.TrimSpace(spec), "=")
+		v, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+		if !found || err != nil {
+			return nil, fmt.Errorf("prompt version '%s' must be formatted as 'name=v1'", spec)
+		}
+		if err := r.Pin(name, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Registry) load(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		matches := templateFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return err
+		}
+
+		tmpl, err := template.New(entry.Name()).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("parse prompt %s: %w", entry.Name(), err)
+		}
+
+		name := matches[1]
+		version, _ := strconv.Atoi(matches[2])
+		if _, ok := r.templates[name]; !ok {
+			r.templates[name] = make(map[int]*template.Template)
+		}
+		r.templates[name][version] = tmpl
+		log.Debug().Msgf("loaded prompt %s v%d", name, version)
+	}
+
+	return nil
+}
+
+// Pin makes the given version the default version of the prompt instead of the latest one.
+func (r *Registry) Pin(name string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[name][version]; !ok {
+		return fmt.Errorf("prompt %s v%d does not exist", name, version)
+	}
+	r.pinned[name] = version
+	return nil
+}
+
+// Render renders the default version of the prompt, which is the pinned or the latest version.
+func (r *Registry) Render(name string, data interface{}) (Prompt, error) {
+	return r.RenderVersion(name, r.defaultVersion(name), data)
+}
+
+// RenderVersion renders the given version of the prompt.
+func (r *Registry) RenderVersion(name string, version int, data interface{}) (Prompt, error) {
+	r.mu.RLock()
+	tmpl, ok := r.templates[name][version]
+	r.mu.RUnlock()
+
+	if !ok {
+		return Prompt{}, fmt.Errorf("prompt %s v%d does not exist", name, version)
+	}
+
+	sb := strings.Builder{}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return Prompt{}, fmt.Errorf("render prompt %s v%d: %w", name, version, err)
+	}
+
+	return Prompt{
+		Name:    name,
+		Version: FormatVersion(version),
+		Text:    strings.TrimSpace(sb.String()),
+	}, nil
+}
+
+func (r *Registry) defaultVersion(name string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if v, ok := r.pinned[name]; ok {
+		return v
+	}
+
+	latest := 0
+	for v := range r.templates[name] {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
+
+func FormatVersion(version int) string {
+	return fmt.Sprintf("v%d", version)
+}
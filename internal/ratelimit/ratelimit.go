@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter is a token bucket refilled with limit tokens per period, which is also its capacity.
+// A nil Limiter does not limit.
+type Limiter struct {
+	limiter *rate.Limiter
+}
+
+// New returns a limiter allowing limit tokens per period. It returns nil if the limit is not positive.
+func New(limit int, per time.Duration) *Limiter {
+	if limit <= 0 || per <= 0 {
+		return nil
+	}
+
+	return &Limiter{
+		limiter: rate.NewLimiter(rate.Limit(float64(limit)/per.Seconds()), limit),
+	}
+}
+
+// Wait blocks until n tokens are available or the context is done.
+// Requests bigger than the capacity wait for the full capacity instead of failing.
+func (l *Limiter) Wait(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+
+	if n > l.limiter.Burst() {
+		n = l.limiter.Burst()
+	}
+	return l.limiter.WaitN(ctx, n)
+}
+
+// Charge takes n tokens without waiting, e.g. to account for a usage only known once the call is made.
+// The tokens may go into debt, which the next calls wait for.
+func (l *Limiter) Charge(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.limiter.ReserveN(time.Now(), min(n, l.limiter.Burst()))
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		per     time.Duration
+		wantNil bool
+	}{
+		{"limited", 10, time.Minute, false},
+		{"zero limit", 0, time.Minute, true},
+		{"negative limit", -1, time.Minute, true},
+		{"zero period", 10, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.limit, tt.per); (got == nil) != tt.wantNil {
+				t.Errorf("got %v, want nil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+// waited returns how long the wait of n tokens blocked
+func waited(t *testing.T, l *Limiter, n int) time.Duration {
+	t.Helper()
+
+	start := time.Now()
+	if err := l.Wait(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	return time.Since(start)
+}
+
+func TestWait(t *testing.T) {
+	// 10 tokens per 100ms, a token every 10ms
+	const per = time.Millisecond * 100
+
+	tests := []struct {
+		name    string
+		charges []int // the tokens charged before the wait
+		n       int
+		minWait time.Duration
+		maxWait time.Duration
+	}{
+		{"within the capacity", nil, 10, 0, per / 4},
+		{"a nil limiter does not limit", []int{10}, 0, 0, per / 4},
+		{"waits for the refill", []int{10}, 5, per / 4, per},
+		{"bigger than the capacity waits for the full capacity", []int{10}, 1000, per / 2, per * 2},
+		{"a charge is clamped to the capacity", []int{1000}, 1, 0, per / 2},
+		{"waits for the charged debt", []int{10, 10}, 1, per, per * 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(10, per)
+			if tt.n == 0 {
+				l = nil
+			}
+			for _, n := range tt.charges {
+				l.Charge(n)
+			}
+
+			if got := waited(t, l, tt.n); got < tt.minWait || got > tt.maxWait {
+				t.Errorf("waited %s, want between %s and %s", got, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+}
+
+func TestWaitContextDone(t *testing.T) {
+	l := New(1, time.Hour)
+	if err := l.Wait(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if err := l.Wait(ctx, 1); err == nil {
+		t.Error("got no error, want the wait to fail with the context")
+	} else if errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want the wait to fail before the deadline is reached", err)
+	}
+}
+
+func TestCharge(t *testing.T) {
+	// a nil limiter and non positive charges are ignored
+	var nilLimiter *Limiter
+	nilLimiter.Charge(10)
+
+	l := New(10, time.Minute)
+	l.Charge(0)
+	l.Charge(-5)
+	if got := waited(t, l, 10); got > time.Millisecond*50 {
+		t.Errorf("waited %s, want the capacity untouched", got)
+	}
+}
@@ -0,0 +1,16 @@
+package enrichmentjobs
+
+const (
+	// collection name
+	enrichmentJobsNode string = "enrichmentJobs"
+
+	// Fields' name and path
+	IdFieldPath         string = "id"
+	ProductIdFieldPath  string = "productId"
+	EnrichmentFieldPath string = "enrichment"
+	StatusFieldPath     string = "status"
+	CreatedAtFieldPath  string = "createdAt"
+	UpdatedAtFieldPath  string = "updatedAt"
+
+	CancelRequestedFieldPath string = "cancelRequested"
+)
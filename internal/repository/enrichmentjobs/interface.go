@@ -0,0 +1,23 @@
+package enrichmentjobs
+
+import (
+	"context"
+
+	"go-firestore-gpt/internal/model"
+)
+
+type IRepository interface {
+	// Save creates or overwrites the job, a job without id is given a new one
+	Save(ctx context.Context, data model.EnrichmentJob) (model.EnrichmentJob, error)
+	// GetById returns errors.NotFound if the job does not exist
+	GetById(ctx context.Context, id string) (*model.EnrichmentJob, error)
+	// Latest returns the most recently created job of the enrichment of the product, nil if there is none
+	Latest(ctx context.Context, productId, enrichment string) (*model.EnrichmentJob, error)
+	// RequestCancel marks the job to be canceled by the worker running it, errors.NotFound if the job does not exist
+	RequestCancel(ctx context.Context, id string) error
+	// NotifyOnCancelRequested calls fn with the id of every running job whose cancel is requested, including the ones
+	// requested before the call. It blocks until the context is done or the listener fails.
+	NotifyOnCancelRequested(ctx context.Context, fn func(id string))
+	// List returns at most limit jobs, newest first. An empty productId lists the jobs of every product.
+	List(ctx context.Context, productId string, limit int) ([]model.EnrichmentJob, error)
+}
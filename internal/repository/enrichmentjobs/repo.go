@@ -0,0 +1,156 @@
+package enrichmentjobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-firestore-gpt/internal/database"
+	ierr "go-firestore-gpt/internal/errors"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/repository/filter"
+	"go-firestore-gpt/internal/repository/helper"
+	"go-firestore-gpt/internal/repository/ops"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type EnrichmentJobsRepository struct {
+	db database.Client
+}
+
+var _ IRepository = EnrichmentJobsRepository{}
+
+func New(db database.Client) EnrichmentJobsRepository {
+	return EnrichmentJobsRepository{
+		db: db,
+	}
+}
+
+func (r EnrichmentJobsRepository) Save(ctx context.Context, data model.EnrichmentJob) (model.EnrichmentJob, error) {
+
+	coll := r.db.Collection(enrichmentJobsNode)
+	docRef := coll.NewDoc()
+	if data.Id != "" {
+		docRef = coll.Doc(data.Id)
+	}
+
+	data.Id = docRef.ID
+	data.UpdatedAt = time.Now().UTC()
+	if data.CreatedAt.IsZero() {
+		data.CreatedAt = data.UpdatedAt
+	}
+
+	if _, err := r.db.SetDoc(ctx, docRef, data); err != nil {
+		return data, fmt.Errorf("save enrichment job: %w, id: %s", err, data.Id)
+	}
+	return data, nil
+}
+
+func (r EnrichmentJobsRepository) GetById(ctx context.Context, id string) (*model.EnrichmentJob, error) {
+
+	docSnap, err := r.db.GetDoc(ctx, r.db.Collection(enrichmentJobsNode).Doc(id))
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ierr.NotFound
+		}
+		return nil, fmt.Errorf("get enrichment job: %w, id: %s", err, id)
+	}
+
+	job := &model.EnrichmentJob{}
+	if err := docSnap.DataTo(job); err != nil {
+		return nil, fmt.Errorf("get enrichment job: %w, id: %s", err, id)
+	}
+	return job, nil
+}
+
+func (r EnrichmentJobsRepository) RequestCancel(ctx context.Context, id string) error {
+
+	docRef := r.db.Collection(enrichmentJobsNode).Doc(id)
+	updates := []firestore.Update{
+		{Path: CancelRequestedFieldPath, Value: true},
+		{Path: UpdatedAtFieldPath, Value: time.Now().UTC()},
+	}
+
+	if _, err := r.db.UpdateDoc(ctx, docRef, updates, firestore.Exists); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ierr.NotFound
+		}
+		return fmt.Errorf("request cancel of enrichment job: %w, id: %s", err, id)
+	}
+	return nil
+}
+
+func (r EnrichmentJobsRepository) NotifyOnCancelRequested(ctx context.Context, fn func(id string)) {
+
+	// a running job enters the results once its cancel is requested
+	query := r.db.Collection(enrichmentJobsNode).Query
+	where := []filter.Where{
+		{Path: StatusFieldPath, Op: ops.Equal, Value: string(model.JobRunning)},
+		{Path: CancelRequestedFieldPath, Op: ops.Equal, Value: true},
+	}
+
+	helper.NotifyOnChanges(ctx, r.db, query, where, firestore.DocumentAdded, func(dc firestore.DocumentChange, err error) error {
+		if err != nil {
+			log.Error().Err(err).Msg("failed to watch the cancels of the enrichment jobs")
+			return err
+		}
+		fn(dc.Doc.Ref.ID)
+		return nil
+	})
+}
+
+func (r EnrichmentJobsRepository) Latest(ctx context.Context, productId, enrichment string) (*model.EnrichmentJob, error) {
+
+	// It requires a composite index on productId, enrichment and createdAt
+	query := r.db.Collection(enrichmentJobsNode).Query.
+		Where(ProductIdFieldPath, ops.Equal, productId).
+		Where(EnrichmentFieldPath, ops.Equal, enrichment).
+		OrderBy(CreatedAtFieldPath, firestore.Desc).
+		Limit(1)
+
+	jobs, err := r.list(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get latest enrichment job: %w, id: %s", err, productId)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+func (r EnrichmentJobsRepository) List(ctx context.Context, productId string, limit int) ([]model.EnrichmentJob, error) {
+
+	query := r.db.Collection(enrichmentJobsNode).Query
+	if productId != "" {
+		query = query.Where(ProductIdFieldPath, ops.Equal, productId)
+	}
+	query = query.OrderBy(CreatedAtFieldPath, firestore.Desc).Limit(limit)
+
+	jobs, err := r.list(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list enrichment jobs: %w, id: %s", err, productId)
+	}
+	return jobs, nil
+}
+
+func (r EnrichmentJobsRepository) list(ctx context.Context, query firestore.Query) ([]model.EnrichmentJob, error) {
+
+	docs, err := r.db.GetDocs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]model.EnrichmentJob, 0, len(docs))
+	for _, doc := range docs {
+		job := model.EnrichmentJob{}
+		if err := doc.DataTo(&job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
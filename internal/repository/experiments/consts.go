@@ -0,0 +1,13 @@
+package experiments
+
+const (
+	// collection name
+	experimentResultsNode string = "experimentResults"
+
+	// Fields' name and path
+	ExperimentFieldPath string = "experiment"
+	EnrichmentFieldPath string = "enrichment"
+	ProductIdFieldPath  string = "productId"
+	VariantFieldPath    string = "variant"
+	CreatedAtFieldPath  string = "createdAt"
+)
@@ -0,0 +1,12 @@
+package experiments
+
+import (
+	"context"
+
+	"go-firestore-gpt/internal/model"
+)
+
+type IRepository interface {
+	Create(ctx context.Context, data model.ExperimentResult) error
+	ListByExperiment(ctx context.Context, experiment string) ([]model.ExperimentResult, error)
+}
@@ -0,0 +1,56 @@
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-firestore-gpt/internal/database"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/repository/ops"
+	"go-firestore-gpt/internal/utils"
+)
+
+type ExperimentsRepository struct {
+	db database.Client
+}
+
+var _ IRepository = ExperimentsRepository{}
+
+func New(db database.Client) ExperimentsRepository {
+	return ExperimentsRepository{
+		db: db,
+	}
+}
+
+func (r ExperimentsRepository) Create(ctx context.Context, data model.ExperimentResult) error {
+
+	// A product is processed once per variant, so a re-run overwrites the previous result
+	docId := utils.Hash(fmt.Sprintf("%s/%s/%s", data.Experiment, data.ProductId, data.Variant))
+	docRef := r.db.Collection(experimentResultsNode).Doc(docId)
+	data.CreatedAt = time.Now().UTC()
+
+	if _, err := r.db.SetDoc(ctx, docRef, data); err != nil {
+		return fmt.Errorf("create experiment result: %w, id: %s", err, docId)
+	}
+	return nil
+}
+
+func (r ExperimentsRepository) ListByExperiment(ctx context.Context, experiment string) ([]model.ExperimentResult, error) {
+
+	query := r.db.Collection(experimentResultsNode).Query.Where(ExperimentFieldPath, ops.Equal, experiment)
+	docs, err := r.db.GetDocs(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list experiment results: %w, experiment: %s", err, experiment)
+	}
+
+	results := make([]model.ExperimentResult, 0, len(docs))
+	for _, doc := range docs {
+		result := model.ExperimentResult{}
+		if err := doc.DataTo(&result); err != nil {
+			return nil, fmt.Errorf("list experiment results: %w, experiment: %s", err, experiment)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"go-firestore-gpt/internal/database"
 	"go-firestore-gpt/internal/repository/filter"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -13,11 +14,55 @@ import (
 func NotifyOnChanges(ctx context.Context, db database.Client, query firestore.Query,
 	where []filter.Where, kind firestore.DocumentChangeKind, fn func(firestore.DocumentChange, error) error) {
 
+	notifyOnChanges(ctx, db, query, where, []firestore.DocumentChangeKind{kind}, fn)
+}
+
+// NotifyOnAnyChanges calls fn whenever documents of one of the queries are added, modified or removed, starting with the
+// documents already stored. It blocks until the context is done or a listener fails, whose error it returns.
+func NotifyOnAnyChanges(ctx context.Context, db database.Client, queries []firestore.Query, fn func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	kinds := []firestore.DocumentChangeKind{firestore.DocumentAdded, firestore.DocumentModified, firestore.DocumentRemoved}
+
+	var mu sync.Mutex
+	var listenErr error
+	var wg sync.WaitGroup
+	for _, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			notifyOnChanges(ctx, db, query, nil, kinds, func(_ firestore.DocumentChange, err error) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					// a failed listener stops the others
+					if listenErr == nil {
+						listenErr = err
+					}
+					cancel()
+					return err
+				}
+				fn()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	return listenErr
+}
+
+func notifyOnChanges(ctx context.Context, db database.Client, query firestore.Query,
+	where []filter.Where, kinds []firestore.DocumentChangeKind, fn func(firestore.DocumentChange, error) error) {
+
 	for _, w := range where {
 		query = query.Where(w.Path, w.Op, w.Value)
 	}
 
-	events := db.NotifyOnChanges(ctx, query.Snapshots(ctx), kind)
+	events := db.NotifyOnChanges(ctx, query.Snapshots(ctx), kinds...)
 
 	for e := range events {
 		if e.Err != nil {
@@ -0,0 +1,12 @@
+package llmcache
+
+const (
+	// collection name
+	llmCacheNode string = "llmCache"
+
+	// Fields' name and path
+	KeyFieldPath       string = "key"
+	ValueFieldPath     string = "value"
+	ExpiresAtFieldPath string = "expiresAt"
+	CreatedAtFieldPath string = "createdAt"
+)
@@ -0,0 +1,12 @@
+package llmcache
+
+import (
+	"context"
+
+	"go-firestore-gpt/internal/model"
+)
+
+type IRepository interface {
+	GetById(ctx context.Context, key string) (*model.LLMCacheEntry, error)
+	Set(ctx context.Context, data model.LLMCacheEntry) error
+}
@@ -0,0 +1,59 @@
+package llmcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-firestore-gpt/internal/database"
+	"go-firestore-gpt/internal/model"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type LLMCacheRepository struct {
+	db database.Client
+}
+
+var _ IRepository = LLMCacheRepository{}
+
+func New(db database.Client) LLMCacheRepository {
+	return LLMCacheRepository{
+		db: db,
+	}
+}
+
+func (r LLMCacheRepository) GetById(ctx context.Context, key string) (*model.LLMCacheEntry, error) {
+
+	docSnap, err := r.db.GetDoc(ctx, r.db.Collection(llmCacheNode).Doc(key))
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get llm cache entry: %w, key: %s", err, key)
+	}
+
+	if !docSnap.Exists() {
+		return nil, nil
+	}
+
+	entry := &model.LLMCacheEntry{}
+	if err := docSnap.DataTo(entry); err != nil {
+		return nil, fmt.Errorf("get llm cache entry: %w, key: %s", err, key)
+	}
+	return entry, nil
+}
+
+func (r LLMCacheRepository) Set(ctx context.Context, data model.LLMCacheEntry) error {
+	if data.Key == nil {
+		return fmt.Errorf("failed to set, LLMCacheEntry.Key is nil")
+	}
+
+	data.CreatedAt = time.Now().UTC()
+	docRef := r.db.Collection(llmCacheNode).Doc(*data.Key)
+	if _, err := r.db.SetDoc(ctx, docRef, data); err != nil {
+		return fmt.Errorf("set llm cache entry: %w, key: %s", err, *data.Key)
+	}
+	return nil
+}
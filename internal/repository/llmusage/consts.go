@@ -0,0 +1,18 @@
+package llmusage
+
+const (
+	// collection name
+	llmUsageNode string = "llmUsage"
+
+	// Fields' name and path
+	DayFieldPath              string = "day"
+	MonthFieldPath            string = "month"
+	ProductIdFieldPath        string = "productId"
+	EnrichmentFieldPath       string = "enrichment"
+	ModelFieldPath            string = "model"
+	CallsFieldPath            string = "calls"
+	PromptTokensFieldPath     string = "promptTokens"
+	CompletionTokensFieldPath string = "completionTokens"
+	CostFieldPath             string = "cost"
+	UpdatedAtFieldPath        string = "updatedAt"
+)
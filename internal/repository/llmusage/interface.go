@@ -0,0 +1,13 @@
+package llmusage
+
+import (
+	"context"
+
+	"go-firestore-gpt/internal/model"
+)
+
+type IRepository interface {
+	// Increment adds the counters of the usage to the stored usage of the same day, product, enrichment and model
+	Increment(ctx context.Context, data model.LLMUsage) error
+	MonthlyCost(ctx context.Context, month string) (float64, error)
+}
@@ -0,0 +1,70 @@
+package llmusage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-firestore-gpt/internal/database"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/repository/ops"
+	"go-firestore-gpt/internal/utils"
+
+	"cloud.google.com/go/firestore"
+)
+
+type LLMUsageRepository struct {
+	db database.Client
+}
+
+var _ IRepository = LLMUsageRepository{}
+
+func New(db database.Client) LLMUsageRepository {
+	return LLMUsageRepository{
+		db: db,
+	}
+}
+
+func (r LLMUsageRepository) Increment(ctx context.Context, data model.LLMUsage) error {
+
+	docId := utils.Hash(fmt.Sprintf("%s/%s/%s/%s", data.Day, data.ProductId, data.Enrichment, data.Model))
+	docRef := r.db.Collection(llmUsageNode).Doc(docId)
+
+	// Increments are applied atomically by firestore, so concurrent workers do not lose updates
+	_, err := r.db.SetDoc(ctx, docRef, map[string]interface{}{
+		DayFieldPath:              data.Day,
+		MonthFieldPath:            data.Month,
+		ProductIdFieldPath:        data.ProductId,
+		EnrichmentFieldPath:       data.Enrichment,
+		ModelFieldPath:            data.Model,
+		CallsFieldPath:            firestore.Increment(data.Calls),
+		PromptTokensFieldPath:     firestore.Increment(data.PromptTokens),
+		CompletionTokensFieldPath: firestore.Increment(data.CompletionTokens),
+		CostFieldPath:             firestore.Increment(data.Cost),
+		UpdatedAtFieldPath:        time.Now().UTC(),
+	}, firestore.MergeAll)
+
+	if err != nil {
+		return fmt.Errorf("increment llm usage: %w, id: %s", err, docId)
+	}
+	return nil
+}
+
+func (r LLMUsageRepository) MonthlyCost(ctx context.Context, month string) (float64, error) {
+
+	query := r.db.Collection(llmUsageNode).Query.Where(MonthFieldPath, ops.Equal, month)
+	docs, err := r.db.GetDocs(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("get monthly llm cost: %w, month: %s", err, month)
+	}
+
+	cost := 0.0
+	for _, doc := range docs {
+		usage := model.LLMUsage{}
+		if err := doc.DataTo(&usage); err != nil {
+			return 0, fmt.Errorf("get monthly llm cost: %w, month: %s", err, month)
+		}
+		cost += usage.Cost
+	}
+	return cost, nil
+}
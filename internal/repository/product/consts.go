@@ -12,6 +12,8 @@ const (
 	IdFieldPath                string = "id"
 	NameFieldPath              string = "name"
 	DescriptionFieldPath       string = "description"
+	RegionFieldPath            string = "region"
+	LanguageFieldPath          string = "language"
 	SentimentAnalizedFieldPath string = "sentimentAnalized"
 	RelatedVideosAnalized      string = "relatedVideosAnalized"
 	CreatedAtFieldPath         string = "createdAt"
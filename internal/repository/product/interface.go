@@ -9,7 +9,12 @@ import (
 
 type IRepository interface {
 	GetById(ctx context.Context, id string) (*model.Product, error)
-	Create(ctx context.Context, data model.Product) error
+	// Create returns the stored product, errors.AlreadyExists if the product exists
+	Create(ctx context.Context, data model.Product) (model.Product, error)
 	Update(ctx context.Context, id string, data model.Product) error
+	// AddReviews adds the reviews to the product, it returns errors.NotFound if the product does not exist
+	AddReviews(ctx context.Context, id string, reviews []model.ProductReview) error
+	// ListIds returns the ids of at most limit products matching the filters
+	ListIds(ctx context.Context, where []filter.Where, limit int) ([]string, error)
 	NotifyOnAdded(ctx context.Context, where []filter.Where) <-chan ProductEvent
 }
@@ -35,7 +35,7 @@ func New(db database.Client) ProductRepository {
 func (r ProductRepository) GetById(ctx context.Context, id string) (product *model.Product, err error) {
 
 	docRef := r.db.Collection(productNode).Doc(id)
-	docSnap, err := docRef.Get(ctx)
+	docSnap, err := r.db.GetDoc(ctx, docRef)
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return nil, ierr.NotFound
@@ -56,37 +56,33 @@ func (r ProductRepository) GetById(ctx context.Context, id string) (product *mod
 	return product, err
 }
 
-func (r ProductRepository) Create(ctx context.Context, data model.Product) error {
+func (r ProductRepository) Create(ctx context.Context, data model.Product) (model.Product, error) {
 
-	p, err := r.GetById(ctx, *data.Id)
-	if p != nil {
-		return fmt.Errorf("create product: %w, id: %s", err, *data.Id)
-	}
-
-	if err != nil && err != ierr.NotFound {
-		return fmt.Errorf("create product: %w, id: %s", err, *data.Id)
-	}
-
-	data.CreatedAt = time.Now().UTC()
+	// the timestamps are stored with a microsecond precision, the returned product holds the stored ones
+	data.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
 	data.UpdatedAt = data.CreatedAt
 	data.RelatedVideosAnalized = utils.BoolToPointer(false)
 	data.SentimentAnalized = utils.BoolToPointer(false)
 	docRef := r.db.Collection(productNode).Doc(*data.Id)
-	_, err = r.db.SetDoc(ctx, docRef, data)
+	// the doc is only created if it does not exist, so concurrent creates of the product can not overwrite each other
+	_, err := r.db.CreateDoc(ctx, docRef, data)
 
+	if status.Code(err) == codes.AlreadyExists {
+		return data, fmt.Errorf("create product: %w, id: %s", ierr.AlreadyExists, *data.Id)
+	}
 	if err != nil {
-		return fmt.Errorf("create product: %w, id: %s", err, *data.Id)
+		return data, fmt.Errorf("create product: %w, id: %s", err, *data.Id)
 	}
 
 	if err := r.addProductReviews(ctx, data); err != nil {
-		return fmt.Errorf("create product: %w, id: %s", err, *data.Id)
+		return data, fmt.Errorf("create product: %w, id: %s", err, *data.Id)
 	}
 
 	if err := r.addProductQAs(ctx, data); err != nil {
-		return fmt.Errorf("create product: %w, id: %s", err, *data.Id)
+		return data, fmt.Errorf("create product: %w, id: %s", err, *data.Id)
 	}
 
-	return nil
+	return data, nil
 }
 
 func (r ProductRepository) Delete(ctx context.Context, id string) error {
@@ -123,6 +119,23 @@ func (r ProductRepository) addProductReviews(ctx context.Context, data model.Pro
 	return nil
 }
 
+func (r ProductRepository) AddReviews(ctx context.Context, id string, reviews []model.ProductReview) error {
+
+	docRef := r.db.Collection(productNode).Doc(id)
+	_, err := r.db.UpdateDoc(ctx, docRef, []firestore.Update{
+		{Path: UpdatedAtFieldPath, Value: time.Now().UTC()},
+	}, firestore.Exists)
+
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ierr.NotFound
+		}
+		return fmt.Errorf("add product reviews: %w, id: %s", err, id)
+	}
+
+	return r.addProductReviews(ctx, model.Product{Id: &id, Reviews: reviews})
+}
+
 func (r ProductRepository) addProductQAs(ctx context.Context, data model.Product) error {
 
 	for _, qa := range data.QAs {
@@ -166,6 +179,25 @@ func (r ProductRepository) Update(ctx context.Context, id string, data model.Pro
 	return nil
 }
 
+func (r ProductRepository) ListIds(ctx context.Context, where []filter.Where, limit int) ([]string, error) {
+
+	query := r.db.Collection(productNode).Query
+	for _, w := range where {
+		query = query.Where(w.Path, w.Op, w.Value)
+	}
+
+	docs, err := r.db.GetDocs(ctx, query.Select().Limit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list products: %w", err)
+	}
+
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.Ref.ID)
+	}
+	return ids, nil
+}
+
 func (r ProductRepository) NotifyOnAdded(ctx context.Context, where []filter.Where) <-chan ProductEvent {
 	query := r.db.Collection(productNode).Query
 	return r.notifyOnChanges(ctx, query, where, firestore.DocumentAdded)
@@ -8,18 +8,37 @@ const (
 	videosNode         string = "videos"
 
 	// relevantVideos's Field names and paths
-	ProductIdFieldPath string = "productId"
-	ReadyFieldPath     string = "ready"
-	CreatedAtFieldPath string = "createdAt"
-	UpdatedAtFieldPath string = "updatedAt"
+	ProductIdFieldPath      string = "productId"
+	ReadyFieldPath          string = "ready"
+	PromptVersionsFieldPath string = "promptVersions"
+	SearchedAtFieldPath     string = "searchedAt"
+	CreatedAtFieldPath      string = "createdAt"
+	UpdatedAtFieldPath      string = "updatedAt"
 
 	// videos's Field names and paths
-	VideoIdFieldPath        string = "id"
-	VideoUrlFieldPath       string = "url"
-	VideoThumbUpFieldPath   string = "thumbup"
-	VideoThumbDownFieldPath string = "thumbdown"
-	VideoCreatedAtFieldPath string = "createdAt"
-	VideoUpdatedAtFieldPath string = "updatedAt"
+	VideoIdFieldPath              string = "id"
+	VideoUrlFieldPath             string = "url"
+	VideoTitleFieldPath           string = "title"
+	VideoSourceFieldPath          string = "source"
+	VideoSourceIdFieldPath        string = "sourceId"
+	VideoQueryFieldPath           string = "query"
+	VideoChannelIdFieldPath       string = "channelId"
+	VideoChannelTitleFieldPath    string = "channelTitle"
+	VideoDurationSecondsFieldPath string = "durationSeconds"
+	VideoViewCountFieldPath       string = "viewCount"
+	VideoLikeCountFieldPath       string = "likeCount"
+	VideoPublishedAtFieldPath     string = "publishedAt"
+	VideoLanguageFieldPath        string = "language"
+	VideoThumbnailUrlFieldPath    string = "thumbnailUrl"
+	VideoRankFieldPath            string = "rank"
+	VideoScoreFieldPath           string = "score"
+	VideoRationaleFieldPath       string = "rationale"
+	VideoThumbUpFieldPath         string = "thumbup"
+	VideoThumbDownFieldPath       string = "thumbdown"
+	VideoDemotedFieldPath         string = "demoted"
+	VideoDemotedAtFieldPath       string = "demotedAt"
+	VideoCreatedAtFieldPath       string = "createdAt"
+	VideoUpdatedAtFieldPath       string = "updatedAt"
 
 	// It must not exceed the write timeout of the database.firestore.notifyOnChanges
 	channelWriteTimeout time.Duration = time.Second * 3
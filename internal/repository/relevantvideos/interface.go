@@ -2,6 +2,7 @@ package relevantvideos
 
 import (
 	"context"
+	"time"
 
 	"go-firestore-gpt/internal/model"
 )
@@ -10,4 +11,20 @@ type IRepository interface {
 	CreateIfNotExist(ctx context.Context, data model.RelevantVideos) error
 	Update(ctx context.Context, data model.RelevantVideos) error
 	NotifyOnAdded(ctx context.Context) <-chan RelevantVideosEvent
+	// GetById returns the relevant videos of the product without the videos, errors.NotFound if there are none
+	GetById(ctx context.Context, productId string) (*model.RelevantVideos, error)
+	GetVideos(ctx context.Context, productId string) ([]model.Video, error)
+	// NotifyOnProductChanges calls fn whenever the videos of the product change, until the context is done
+	NotifyOnProductChanges(ctx context.Context, productId string, fn func()) error
+	// Vote atomically counts the vote of a user on the video, it returns errors.NotFound if the video does not exist
+	Vote(ctx context.Context, productId, videoId string, vote model.Vote) error
+	// DownVotedVideos returns the videos with at least minDownVotes thumbs down, keyed by their product id
+	DownVotedVideos(ctx context.Context, minDownVotes int) (map[string][]model.Video, error)
+	Demote(ctx context.Context, productId, videoId string) error
+	// RequestSearch marks the videos of the product as not ready, so they are searched again
+	RequestSearch(ctx context.Context, productId string) error
+	// CheckedBefore returns at most limit ready relevant videos which were not updated nor checked since the time, oldest first
+	CheckedBefore(ctx context.Context, before time.Time, limit int) ([]model.RelevantVideos, error)
+	MarkChecked(ctx context.Context, productId string) error
+	DeleteVideo(ctx context.Context, productId, videoId string) error
 }
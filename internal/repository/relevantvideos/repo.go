@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"go-firestore-gpt/internal/database"
+	ierr "go-firestore-gpt/internal/errors"
 	"go-firestore-gpt/internal/model"
 	"go-firestore-gpt/internal/repository/filter"
 	"go-firestore-gpt/internal/repository/helper"
@@ -15,6 +16,8 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type RelevantVideosRepository struct {
@@ -51,7 +54,17 @@ func (r RelevantVideosRepository) Update(ctx context.Context, data model.Relevan
 	docRef := r.db.Collection(relevantVideosNode).Doc(*data.ProductId)
 	updates := []firestore.Update{}
 
-	err := r.createVideos(ctx, docRef.ID, data.Videos)
+	stored, err := r.GetVideos(ctx, docRef.ID)
+	if err != nil {
+		return err
+	}
+
+	err = r.createVideos(ctx, docRef.ID, data.Videos, stored)
+	if err != nil {
+		return err
+	}
+
+	err = r.removeStaleVideos(ctx, docRef.ID, data.Videos, stored)
 	if err != nil {
 		return err
 	}
@@ -64,6 +77,20 @@ func (r RelevantVideosRepository) Update(ctx context.Context, data model.Relevan
 		})
 	}
 
+	if !data.SearchedAt.IsZero() {
+		updates = append(updates, firestore.Update{
+			Path:  SearchedAtFieldPath,
+			Value: data.SearchedAt,
+		})
+	}
+
+	if len(data.PromptVersions) > 0 {
+		updates = append(updates, firestore.Update{
+			Path:  PromptVersionsFieldPath,
+			Value: data.PromptVersions,
+		})
+	}
+
 	_, err = r.db.UpdateDoc(ctx, docRef, updates)
 	if err != nil {
 		return fmt.Errorf("update relevant videos: %w, id: %s", err, *data.ProductId)
@@ -89,12 +116,13 @@ func (r RelevantVideosRepository) create(ctx context.Context, data model.Relevan
 		return err
 	}
 
-	err = r.createVideos(ctx, docRef.ID, data.Videos)
+	err = r.createVideos(ctx, docRef.ID, data.Videos, nil)
 
 	return err
 }
 
-func (r RelevantVideosRepository) createVideos(ctx context.Context, id string, videos []model.Video) error {
+// createVideos merges the videos into the stored videos, the createdAt of a stored video is kept
+func (r RelevantVideosRepository) createVideos(ctx context.Context, id string, videos []model.Video, stored []model.Video) error {
 
 	if len(videos) == 0 {
 		return nil
@@ -102,16 +130,48 @@ func (r RelevantVideosRepository) createVideos(ctx context.Context, id string, v
 
 	relevantVideoDoc := r.db.Collection(relevantVideosNode).Doc(id)
 
+	existing := make(map[string]bool, len(stored))
+	for _, video := range stored {
+		if video.Id != nil {
+			existing[*video.Id] = true
+		}
+	}
+
+	now := time.Now().UTC()
 	batchData := []database.DataBatch{}
 	for _, video := range videos {
 		docId := utils.Hash(video.Url)
 		docRef := relevantVideoDoc.Collection(videosNode).Doc(docId)
-		video.Id = &docId
-		video.CreatedAt = time.Now().UTC()
+
+		// only the found fields are merged, so the votes of a video found again are kept
+		data := map[string]interface{}{
+			VideoIdFieldPath:              docId,
+			VideoUrlFieldPath:             video.Url,
+			VideoTitleFieldPath:           video.Title,
+			VideoSourceFieldPath:          video.Source,
+			VideoSourceIdFieldPath:        video.SourceId,
+			VideoQueryFieldPath:           video.Query,
+			VideoChannelIdFieldPath:       video.ChannelId,
+			VideoChannelTitleFieldPath:    video.ChannelTitle,
+			VideoDurationSecondsFieldPath: video.DurationSeconds,
+			VideoViewCountFieldPath:       video.ViewCount,
+			VideoLikeCountFieldPath:       video.LikeCount,
+			VideoPublishedAtFieldPath:     video.PublishedAt,
+			VideoLanguageFieldPath:        video.Language,
+			VideoThumbnailUrlFieldPath:    video.ThumbnailUrl,
+			VideoRankFieldPath:            video.Rank,
+			VideoScoreFieldPath:           video.Score,
+			VideoRationaleFieldPath:       video.Rationale,
+			VideoUpdatedAtFieldPath:       now,
+		}
+		if !existing[docId] {
+			data[VideoCreatedAtFieldPath] = now
+		}
 
 		batchData = append(batchData, database.DataBatch{
 			DocRef: docRef,
-			Data:   video,
+			Data:   data,
+			Opts:   []firestore.SetOption{firestore.MergeAll},
 		})
 	}
 
@@ -124,10 +184,218 @@ func (r RelevantVideosRepository) createVideos(ctx context.Context, id string, v
 	return err
 }
 
+// removeStaleVideos deletes the videos of a previous search which are not among the current videos.
+// The demoted videos are kept, so that they are never suggested again.
+func (r RelevantVideosRepository) removeStaleVideos(ctx context.Context, id string, videos []model.Video, stored []model.Video) error {
+
+	if len(videos) == 0 {
+		return nil
+	}
+
+	current := make(map[string]bool, len(videos))
+	for _, video := range videos {
+		current[utils.Hash(video.Url)] = true
+	}
+
+	for _, video := range stored {
+		if video.Id == nil || current[*video.Id] || video.Demoted {
+			continue
+		}
+
+		docRef := r.db.Collection(relevantVideosNode).Doc(id).Collection(videosNode).Doc(*video.Id)
+		if _, err := r.db.DeleteDoc(ctx, docRef); err != nil {
+			return fmt.Errorf("delete stale relevant video: %w, id: %s", err, *video.Id)
+		}
+	}
+	return nil
+}
+
+func (r RelevantVideosRepository) GetById(ctx context.Context, productId string) (*model.RelevantVideos, error) {
+	rv, err := r.getById(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	if rv == nil {
+		return nil, ierr.NotFound
+	}
+	return rv, nil
+}
+
+func (r RelevantVideosRepository) GetVideos(ctx context.Context, productId string) ([]model.Video, error) {
+
+	docs, err := r.db.GetDocs(ctx, r.db.Collection(relevantVideosNode).Doc(productId).Collection(videosNode).Query)
+	if err != nil {
+		return nil, fmt.Errorf("get relevant video docs: %w, id: %s", err, productId)
+	}
+
+	videos := make([]model.Video, 0, len(docs))
+	for _, doc := range docs {
+		video := model.Video{}
+		if err := doc.DataTo(&video); err != nil {
+			return nil, fmt.Errorf("get relevant video docs: %w, id: %s", err, productId)
+		}
+		if video.Id == nil {
+			video.Id = &doc.Ref.ID
+		}
+		videos = append(videos, video)
+	}
+	return videos, nil
+}
+
+// NotifyOnProductChanges calls fn whenever the relevant videos of the product or its videos change, starting with the
+// stored ones. It blocks until the context is done or the listeners fail.
+func (r RelevantVideosRepository) NotifyOnProductChanges(ctx context.Context, productId string, fn func()) error {
+
+	coll := r.db.Collection(relevantVideosNode)
+	queries := []firestore.Query{
+		coll.Query.Where(ProductIdFieldPath, ops.Equal, productId),
+		coll.Doc(productId).Collection(videosNode).Query,
+	}
+
+	if err := helper.NotifyOnAnyChanges(ctx, r.db, queries, fn); err != nil {
+		return fmt.Errorf("watch relevant videos: %w, id: %s", err, productId)
+	}
+	return nil
+}
+
+func (r RelevantVideosRepository) Vote(ctx context.Context, productId, videoId string, vote model.Vote) error {
+
+	var path string
+	switch vote {
+	case model.VoteUp:
+		path = VideoThumbUpFieldPath
+	case model.VoteDown:
+		path = VideoThumbDownFieldPath
+	default:
+		return fmt.Errorf("unknown vote '%s'", vote)
+	}
+
+	// Increments are applied atomically by firestore, so concurrent votes are not lost
+	docRef := r.db.Collection(relevantVideosNode).Doc(productId).Collection(videosNode).Doc(videoId)
+	_, err := r.db.UpdateDoc(ctx, docRef, []firestore.Update{
+		{Path: path, Value: firestore.Increment(1)},
+		{Path: VideoUpdatedAtFieldPath, Value: time.Now().UTC()},
+	}, firestore.Exists)
+
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ierr.NotFound
+		}
+		return fmt.Errorf("vote relevant video: %w, id: %s/%s", err, productId, videoId)
+	}
+	return nil
+}
+
+func (r RelevantVideosRepository) DownVotedVideos(ctx context.Context, minDownVotes int) (map[string][]model.Video, error) {
+
+	query := r.db.CollectionGroup(videosNode).Where(VideoThumbDownFieldPath, ops.GreaterEqual, minDownVotes)
+	docs, err := r.db.GetDocs(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get down voted videos: %w", err)
+	}
+
+	videos := map[string][]model.Video{}
+	for _, doc := range docs {
+		// other collections may have a videos subcollection too
+		parent := doc.Ref.Parent.Parent
+		if parent == nil || parent.Parent.ID != relevantVideosNode {
+			continue
+		}
+
+		video := model.Video{}
+		if err := doc.DataTo(&video); err != nil {
+			return nil, fmt.Errorf("get down voted videos: %w, id: %s", err, doc.Ref.Path)
+		}
+		if video.Id == nil {
+			video.Id = &doc.Ref.ID
+		}
+		videos[parent.ID] = append(videos[parent.ID], video)
+	}
+	return videos, nil
+}
+
+func (r RelevantVideosRepository) Demote(ctx context.Context, productId, videoId string) error {
+
+	now := time.Now().UTC()
+	docRef := r.db.Collection(relevantVideosNode).Doc(productId).Collection(videosNode).Doc(videoId)
+	_, err := r.db.UpdateDoc(ctx, docRef, []firestore.Update{
+		{Path: VideoDemotedFieldPath, Value: true},
+		{Path: VideoDemotedAtFieldPath, Value: now},
+		{Path: VideoRankFieldPath, Value: firestore.Delete},
+		{Path: VideoUpdatedAtFieldPath, Value: now},
+	})
+
+	if err != nil {
+		return fmt.Errorf("demote relevant video: %w, id: %s/%s", err, productId, videoId)
+	}
+	return nil
+}
+
+func (r RelevantVideosRepository) RequestSearch(ctx context.Context, productId string) error {
+
+	// the listener of NotifyOnAdded sees the document again once it is not ready
+	docRef := r.db.Collection(relevantVideosNode).Doc(productId)
+	_, err := r.db.UpdateDoc(ctx, docRef, []firestore.Update{
+		{Path: ReadyFieldPath, Value: false},
+		{Path: UpdatedAtFieldPath, Value: time.Now().UTC()},
+	})
+
+	if err != nil {
+		return fmt.Errorf("request relevant videos search: %w, id: %s", err, productId)
+	}
+	return nil
+}
+
+func (r RelevantVideosRepository) CheckedBefore(ctx context.Context, before time.Time, limit int) ([]model.RelevantVideos, error) {
+
+	query := r.db.Collection(relevantVideosNode).
+		Where(ReadyFieldPath, ops.Equal, true).
+		Where(UpdatedAtFieldPath, ops.SmallerEqual, before).
+		OrderBy(UpdatedAtFieldPath, firestore.Asc).
+		Limit(limit)
+
+	docs, err := r.db.GetDocs(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get relevant videos checked before %s: %w", before.Format(time.RFC3339), err)
+	}
+
+	rvs := make([]model.RelevantVideos, 0, len(docs))
+	for _, doc := range docs {
+		rv := model.RelevantVideos{}
+		if err := doc.DataTo(&rv); err != nil {
+			return nil, fmt.Errorf("get relevant videos: %w, id: %s", err, doc.Ref.ID)
+		}
+		rvs = append(rvs, rv)
+	}
+	return rvs, nil
+}
+
+func (r RelevantVideosRepository) MarkChecked(ctx context.Context, productId string) error {
+
+	docRef := r.db.Collection(relevantVideosNode).Doc(productId)
+	_, err := r.db.UpdateDoc(ctx, docRef, []firestore.Update{
+		{Path: UpdatedAtFieldPath, Value: time.Now().UTC()},
+	})
+
+	if err != nil {
+		return fmt.Errorf("mark relevant videos checked: %w, id: %s", err, productId)
+	}
+	return nil
+}
+
+func (r RelevantVideosRepository) DeleteVideo(ctx context.Context, productId, videoId string) error {
+
+	docRef := r.db.Collection(relevantVideosNode).Doc(productId).Collection(videosNode).Doc(videoId)
+	if _, err := r.db.DeleteDoc(ctx, docRef); err != nil {
+		return fmt.Errorf("delete relevant video: %w, id: %s/%s", err, productId, videoId)
+	}
+	return nil
+}
+
 func (r RelevantVideosRepository) getById(ctx context.Context, id string) (rv *model.RelevantVideos, err error) {
 
 	query := r.db.Collection(relevantVideosNode).Query.Where(ProductIdFieldPath, ops.Equal, id)
-	docs, err := query.Documents(ctx).GetAll()
+	docs, err := r.db.GetDocs(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("get relevant videos: %w, id: %s", err, id)
 	}
@@ -9,4 +9,7 @@ import (
 type IRepository interface {
 	Create(ctx context.Context, data model.ReviewSentiments) error
 	GetById(ctx context.Context, id string) (*model.ReviewSentiments, error)
+	GetSentiments(ctx context.Context, productId string) ([]model.Sentiment, error)
+	// NotifyOnProductChanges calls fn whenever the sentiments of the product change, until the context is done
+	NotifyOnProductChanges(ctx context.Context, productId string, fn func()) error
 }
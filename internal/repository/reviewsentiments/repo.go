@@ -6,8 +6,15 @@ import (
 	"time"
 
 	"go-firestore-gpt/internal/database"
+	ierr "go-firestore-gpt/internal/errors"
 	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/repository/helper"
+	"go-firestore-gpt/internal/repository/ops"
 	"go-firestore-gpt/internal/utils"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type ReviewSentimentsRepository struct {
@@ -35,8 +42,13 @@ func (r ReviewSentimentsRepository) Create(ctx context.Context, data model.Revie
 	}
 
 	err = r.createSentiments(ctx, docRef.ID, data.Sentiments)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// an analysis requested again replaces the sentiments of the previous one. The stale labels are only deleted once
+	// the new sentiments are written, so a failure never leaves the product without sentiments.
+	return r.removeStaleSentiments(ctx, docRef.ID, data.Sentiments)
 }
 
 func (r ReviewSentimentsRepository) createSentiments(ctx context.Context, id string, sentiments []model.Sentiment) error {
@@ -69,11 +81,40 @@ func (r ReviewSentimentsRepository) createSentiments(ctx context.Context, id str
 	return err
 }
 
+// removeStaleSentiments deletes the sentiments of a previous analysis whose labels are not among the current sentiments.
+func (r ReviewSentimentsRepository) removeStaleSentiments(ctx context.Context, id string, sentiments []model.Sentiment) error {
+
+	current := make(map[string]bool, len(sentiments))
+	for _, sentiment := range sentiments {
+		current[sentiment.Label] = true
+	}
+
+	stored, err := r.GetSentiments(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, sentiment := range stored {
+		if current[sentiment.Label] {
+			continue
+		}
+
+		docRef := r.db.Collection(reviewSentimentsNode).Doc(id).Collection(sentimentsNode).Doc(utils.Hash(sentiment.Label))
+		if _, err := r.db.DeleteDoc(ctx, docRef); err != nil {
+			return fmt.Errorf("delete stale review sentiment: %w, id: %s, label: %s", err, id, sentiment.Label)
+		}
+	}
+	return nil
+}
+
 func (r ReviewSentimentsRepository) GetById(ctx context.Context, id string) (rv *model.ReviewSentiments, err error) {
 
 	docRef := r.db.Collection(reviewSentimentsNode).Doc(id)
-	docSnap, err := docRef.Get(ctx)
+	docSnap, err := r.db.GetDoc(ctx, docRef)
 	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ierr.NotFound
+		}
 		return nil, fmt.Errorf("get review sentiments: %w, id: %s", err, id)
 	}
 
@@ -87,3 +128,37 @@ func (r ReviewSentimentsRepository) GetById(ctx context.Context, id string) (rv
 	}
 	return rv, nil
 }
+
+func (r ReviewSentimentsRepository) GetSentiments(ctx context.Context, productId string) ([]model.Sentiment, error) {
+
+	docs, err := r.db.GetDocs(ctx, r.db.Collection(reviewSentimentsNode).Doc(productId).Collection(sentimentsNode).Query)
+	if err != nil {
+		return nil, fmt.Errorf("get sentiment docs: %w, id: %s", err, productId)
+	}
+
+	sentiments := make([]model.Sentiment, 0, len(docs))
+	for _, doc := range docs {
+		sentiment := model.Sentiment{}
+		if err := doc.DataTo(&sentiment); err != nil {
+			return nil, fmt.Errorf("get sentiment docs: %w, id: %s", err, productId)
+		}
+		sentiments = append(sentiments, sentiment)
+	}
+	return sentiments, nil
+}
+
+// NotifyOnProductChanges calls fn whenever the review sentiments of the product or its sentiments change, starting with
+// the stored ones. It blocks until the context is done or the listeners fail.
+func (r ReviewSentimentsRepository) NotifyOnProductChanges(ctx context.Context, productId string, fn func()) error {
+
+	coll := r.db.Collection(reviewSentimentsNode)
+	queries := []firestore.Query{
+		coll.Query.Where(ProductIdFieldPath, ops.Equal, productId),
+		coll.Doc(productId).Collection(sentimentsNode).Query,
+	}
+
+	if err := helper.NotifyOnAnyChanges(ctx, r.db, queries, fn); err != nil {
+		return fmt.Errorf("watch review sentiments: %w, id: %s", err, productId)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package youtubequota
+
+const (
+	// collection name
+	youtubeQuotaNode string = "youtubeQuota"
+
+	// Fields' name and path
+	DayFieldPath       string = "day"
+	UnitsFieldPath     string = "units"
+	UpdatedAtFieldPath string = "updatedAt"
+)
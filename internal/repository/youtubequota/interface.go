@@ -0,0 +1,12 @@
+package youtubequota
+
+import (
+	"context"
+)
+
+type IRepository interface {
+	// Increment adds the units to the stored units of the day
+	Increment(ctx context.Context, day string, units int) error
+	// UnitsOfDay returns the stored units of the day, zero if none were spent
+	UnitsOfDay(ctx context.Context, day string) (int, error)
+}
@@ -0,0 +1,64 @@
+package youtubequota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-firestore-gpt/internal/database"
+	"go-firestore-gpt/internal/model"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type YouTubeQuotaRepository struct {
+	db database.Client
+}
+
+var _ IRepository = YouTubeQuotaRepository{}
+
+func New(db database.Client) YouTubeQuotaRepository {
+	return YouTubeQuotaRepository{
+		db: db,
+	}
+}
+
+func (r YouTubeQuotaRepository) Increment(ctx context.Context, day string, units int) error {
+
+	docRef := r.db.Collection(youtubeQuotaNode).Doc(day)
+
+	// Increments are applied atomically by firestore, so concurrent workers do not lose updates
+	_, err := r.db.SetDoc(ctx, docRef, map[string]interface{}{
+		DayFieldPath:       day,
+		UnitsFieldPath:     firestore.Increment(units),
+		UpdatedAtFieldPath: time.Now().UTC(),
+	}, firestore.MergeAll)
+
+	if err != nil {
+		return fmt.Errorf("increment youtube quota: %w, day: %s", err, day)
+	}
+	return nil
+}
+
+func (r YouTubeQuotaRepository) UnitsOfDay(ctx context.Context, day string) (int, error) {
+
+	docSnap, err := r.db.GetDoc(ctx, r.db.Collection(youtubeQuotaNode).Doc(day))
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("get youtube quota: %w, day: %s", err, day)
+	}
+
+	if !docSnap.Exists() {
+		return 0, nil
+	}
+
+	quota := model.YouTubeQuota{}
+	if err := docSnap.DataTo(&quota); err != nil {
+		return 0, fmt.Errorf("get youtube quota: %w, day: %s", err, day)
+	}
+	return quota.Units, nil
+}
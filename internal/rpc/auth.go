@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	enrichmentv1 "go-firestore-gpt/api/enrichment/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// the calls which write the products, the reads of the enrichments are not authenticated
+var ingestMethods = map[string]bool{
+	enrichmentv1.EnrichmentService_CreateProduct_FullMethodName: true,
+	enrichmentv1.EnrichmentService_AddReviews_FullMethodName:    true,
+}
+
+// bearer only serves the ingest calls carrying the token as a bearer token in their authorization metadata.
+// Like the admin endpoints of the http api, they are not served when the token is not set.
+func bearer(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !ingestMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		if token == "" {
+			return nil, status.Errorf(codes.Unimplemented, "method %s is disabled", info.FullMethod)
+		}
+
+		var got string
+		ok := false
+		if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+			got, ok = strings.CutPrefix(values[0], "Bearer ")
+		}
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,182 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	enrichmentv1 "go-firestore-gpt/api/enrichment/v1"
+	ierr "go-firestore-gpt/internal/errors"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/utils"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) GetEnrichments(ctx context.Context, req *enrichmentv1.GetEnrichmentsRequest) (*enrichmentv1.Enrichments, error) {
+	if req.GetProductId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "product id is required")
+	}
+	if _, err := s.productRepo.GetById(ctx, req.GetProductId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	enrichments, err := s.enrichments(ctx, req.GetProductId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return enrichments, nil
+}
+
+// WatchEnrichments sends the enrichments of the product, then sends them again whenever the snapshot listeners of the
+// sentiments or the videos report a change, until the client cancels the stream.
+func (s *Server) WatchEnrichments(req *enrichmentv1.WatchEnrichmentsRequest, stream grpc.ServerStreamingServer[enrichmentv1.Enrichments]) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	productId := req.GetProductId()
+	if productId == "" {
+		return status.Error(codes.InvalidArgument, "product id is required")
+	}
+	if _, err := s.productRepo.GetById(ctx, productId); err != nil {
+		return toStatus(err)
+	}
+
+	// the changes are coalesced, a pending one is enough to read the enrichments again
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	listenErr := make(chan error, 2)
+	go func() {
+		listenErr <- s.reviewSentimentsRepo.NotifyOnProductChanges(ctx, productId, notify)
+	}()
+	go func() {
+		listenErr <- s.relevantVideosRepo.NotifyOnProductChanges(ctx, productId, notify)
+	}()
+
+	var sent *enrichmentv1.Enrichments
+	for {
+		enrichments, err := s.enrichments(ctx, productId)
+		if err != nil {
+			return toStatus(err)
+		}
+		if sent == nil || !proto.Equal(enrichments, sent) {
+			if err := stream.Send(enrichments); err != nil {
+				return err
+			}
+			sent = enrichments
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case err := <-listenErr:
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			log.Error().Err(err).Msgf("the watch of the enrichments of product %s stopped", productId)
+			return status.Error(codes.Unavailable, "the watch of the enrichments stopped")
+		case <-changed:
+		}
+
+		// an enrichment writes its documents one by one, they are sent at once
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-time.After(minWatchInterval):
+		}
+	}
+}
+
+// enrichments reads the enrichments of the product done so far
+func (s *Server) enrichments(ctx context.Context, productId string) (*enrichmentv1.Enrichments, error) {
+	enrichments := &enrichmentv1.Enrichments{ProductId: productId}
+	var updatedAt time.Time
+
+	reviewSentiments, err := s.reviewSentimentsRepo.GetById(ctx, productId)
+	if err != nil && !errors.Is(err, ierr.NotFound) {
+		return nil, err
+	}
+	if reviewSentiments != nil {
+		sentiments, err := s.reviewSentimentsRepo.GetSentiments(ctx, productId)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(sentiments, func(i, j int) bool {
+			return sentiments[i].Label < sentiments[j].Label
+		})
+
+		enrichments.SentimentsDone = true
+		for _, sentiment := range sentiments {
+			enrichments.Sentiments = append(enrichments.Sentiments, &enrichmentv1.Sentiment{
+				Label: sentiment.Label,
+				Score: int32(sentiment.Score),
+			})
+		}
+		updatedAt = reviewSentiments.UpdatedAt
+	}
+
+	relevantVideos, err := s.relevantVideosRepo.GetById(ctx, productId)
+	if err != nil && !errors.Is(err, ierr.NotFound) {
+		return nil, err
+	}
+	if relevantVideos != nil {
+		videos, err := s.relevantVideosRepo.GetVideos(ctx, productId)
+		if err != nil {
+			return nil, err
+		}
+
+		enrichments.VideosReady = relevantVideos.Ready != nil && *relevantVideos.Ready
+		enrichments.Videos = toVideos(videos)
+		if relevantVideos.UpdatedAt.After(updatedAt) {
+			updatedAt = relevantVideos.UpdatedAt
+		}
+	}
+
+	if !updatedAt.IsZero() {
+		enrichments.UpdateTime = timestamppb.New(updatedAt)
+	}
+	return enrichments, nil
+}
+
+// toVideos returns the videos which are not demoted, best ranked first
+func toVideos(videos []model.Video) []*enrichmentv1.Video {
+	result := []*enrichmentv1.Video{}
+	for _, video := range videos {
+		if video.Demoted {
+			continue
+		}
+		result = append(result, &enrichmentv1.Video{
+			Id:           utils.StringFromPointer(video.Id),
+			Url:          video.Url,
+			Title:        video.Title,
+			Source:       video.Source,
+			ThumbnailUrl: video.ThumbnailUrl,
+			Rank:         int32(video.Rank),
+			Score:        video.Score,
+		})
+	}
+
+	// the unranked videos, e.g. stored before the ranking, come last
+	sort.SliceStable(result, func(i, j int) bool {
+		ri, rj := result[i].Rank, result[j].Rank
+		if (ri == 0) != (rj == 0) {
+			return rj == 0
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return result[i].Id < result[j].Id
+	})
+	return result
+}
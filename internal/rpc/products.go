@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	enrichmentv1 "go-firestore-gpt/api/enrichment/v1"
+	ierr "go-firestore-gpt/internal/errors"
+	"go-firestore-gpt/internal/model"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateProduct creates the product, whose enrichments are triggered like the ones of the products written by the client.
+func (s *Server) CreateProduct(ctx context.Context, req *enrichmentv1.CreateProductRequest) (*enrichmentv1.Product, error) {
+	p := req.GetProduct()
+	if p.GetId() == "" || p.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "product id and name are required")
+	}
+
+	stored, err := s.productRepo.Create(ctx, toModelProduct(p))
+	if errors.Is(err, ierr.AlreadyExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "product %s already exists", p.GetId())
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	created := &enrichmentv1.Product{
+		Id:          p.GetId(),
+		Name:        p.GetName(),
+		Description: p.GetDescription(),
+		Region:      p.GetRegion(),
+		Language:    p.GetLanguage(),
+		Reviews:     p.GetReviews(),
+		Qas:         p.GetQas(),
+		CreateTime:  timestamppb.New(stored.CreatedAt),
+	}
+	return created, nil
+}
+
+// AddReviews adds the reviews to the product. The sentiments which are already analyzed are not analyzed again,
+// an admin can request it with the http api.
+func (s *Server) AddReviews(ctx context.Context, req *enrichmentv1.AddReviewsRequest) (*enrichmentv1.AddReviewsResponse, error) {
+	if req.GetProductId() == "" || len(req.GetReviews()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "product id and reviews are required")
+	}
+
+	reviews := make([]model.ProductReview, 0, len(req.GetReviews()))
+	for _, review := range req.GetReviews() {
+		reviews = append(reviews, toModelReview(review))
+	}
+
+	if err := s.productRepo.AddReviews(ctx, req.GetProductId(), reviews); err != nil {
+		return nil, toStatus(err)
+	}
+	return &enrichmentv1.AddReviewsResponse{Added: int32(len(reviews))}, nil
+}
+
+func toModelProduct(p *enrichmentv1.Product) model.Product {
+	product := model.Product{
+		Id:          stringOrNil(p.GetId()),
+		Name:        stringOrNil(p.GetName()),
+		Description: stringOrNil(p.GetDescription()),
+		Region:      stringOrNil(p.GetRegion()),
+		Language:    stringOrNil(p.GetLanguage()),
+	}
+	for _, review := range p.GetReviews() {
+		product.Reviews = append(product.Reviews, toModelReview(review))
+	}
+	for _, qa := range p.GetQas() {
+		product.QAs = append(product.QAs, model.ProductQA{
+			Question: stringOrNil(qa.GetQuestion()),
+			Answer:   stringOrNil(qa.GetAnswer()),
+		})
+	}
+	return product
+}
+
+func toModelReview(review *enrichmentv1.Review) model.ProductReview {
+	rating := int(review.GetRating())
+	return model.ProductReview{
+		Rating:  &rating,
+		Comment: stringOrNil(review.GetComment()),
+	}
+}
+
+// stringOrNil leaves the empty fields of the messages out of the documents
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
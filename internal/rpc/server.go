@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	enrichmentv1 "go-firestore-gpt/api/enrichment/v1"
+	"go-firestore-gpt/internal/breaker"
+	"go-firestore-gpt/internal/config"
+	ierr "go-firestore-gpt/internal/errors"
+	productRepository "go-firestore-gpt/internal/repository/product"
+	relevantVideosRepository "go-firestore-gpt/internal/repository/relevantvideos"
+	reviewSentimentsRepository "go-firestore-gpt/internal/repository/reviewsentiments"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// the streams are canceled if they do not end within the timeout of the shutdown
+	shutdownTimeout = time.Second * 3
+	// the watched enrichments are not read more often than this, so the writes of an enrichment are sent at once
+	minWatchInterval = time.Second
+)
+
+// Server implements the grpc service which ingests the products and serves their enrichments.
+type Server struct {
+	enrichmentv1.UnimplementedEnrichmentServiceServer
+	productRepo          productRepository.IRepository
+	reviewSentimentsRepo reviewSentimentsRepository.IRepository
+	relevantVideosRepo   relevantVideosRepository.IRepository
+	cnf                  config.GRPC
+}
+
+var _ enrichmentv1.EnrichmentServiceServer = &Server{}
+
+func New(
+	productRepo productRepository.IRepository,
+	reviewSentimentsRepo reviewSentimentsRepository.IRepository,
+	relevantVideosRepo relevantVideosRepository.IRepository,
+	cnf config.GRPC,
+) *Server {
+	return &Server{
+		productRepo:          productRepo,
+		reviewSentimentsRepo: reviewSentimentsRepo,
+		relevantVideosRepo:   relevantVideosRepo,
+		cnf:                  cnf,
+	}
+}
+
+// Serve serves the service on the address until the context is done.
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(bearer(s.cnf.Token)))
+	enrichmentv1.RegisterEnrichmentServiceServer(server, s)
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(shutdownTimeout):
+			server.Stop()
+		}
+	}()
+
+	log.Info().Msgf("serving the grpc service on %s", addr)
+	if err := server.Serve(listener); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// toStatus maps the error of a repository to its status. The unexpected errors are logged and not exposed.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, ierr.NotFound):
+		return status.Error(codes.NotFound, "product not found")
+	case errors.Is(err, breaker.ErrOpen):
+		return status.Error(codes.Unavailable, breaker.ErrOpen.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		log.Error().Err(err).Msg("grpc call failed")
+		return status.Error(codes.Internal, "internal error")
+	}
+}
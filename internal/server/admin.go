@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	ierr "go-firestore-gpt/internal/errors"
+	"go-firestore-gpt/internal/jobs"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/repository/filter"
+	"go-firestore-gpt/internal/repository/ops"
+	productRepository "go-firestore-gpt/internal/repository/product"
+	"go-firestore-gpt/internal/utils"
+)
+
+const (
+	// the most products an enqueue request with a filter selects
+	maxEnqueuedProducts = 500
+	// the most jobs listed by the history
+	maxJobHistory = 1000
+)
+
+var enrichments = []string{model.EnrichmentReviewSentiments, model.EnrichmentRelevantVideos}
+
+type enqueueRequest struct {
+	// either the products or the filter selects the products
+	ProductIds  []string       `json:"productIds,omitempty"`
+	Filter      *productFilter `json:"filter,omitempty"`
+	Enrichments []string       `json:"enrichments,omitempty"` // all of them by default
+}
+
+type productFilter struct {
+	Region        string    `json:"region,omitempty"`
+	Language      string    `json:"language,omitempty"`
+	CreatedAfter  time.Time `json:"createdAfter,omitempty"`
+	CreatedBefore time.Time `json:"createdBefore,omitempty"`
+	Limit         int       `json:"limit,omitempty"`
+}
+
+type enqueueResponse struct {
+	Jobs   []jobResource    `json:"jobs"`
+	Failed []enqueueFailure `json:"failed,omitempty"`
+}
+
+type enqueueFailure struct {
+	ProductId  string `json:"productId"`
+	Enrichment string `json:"enrichment"`
+	Error      string `json:"error"`
+}
+
+type jobResource struct {
+	Id         string `json:"id"`
+	ProductId  string `json:"productId"`
+	Enrichment string `json:"enrichment"`
+	Status     string `json:"status"`
+	Trigger    string `json:"trigger,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// the job runs on another worker, which cancels it
+	CancelRequested bool       `json:"cancelRequested,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	StartedAt       *time.Time `json:"startedAt,omitempty"`
+	FinishedAt      *time.Time `json:"finishedAt,omitempty"`
+}
+
+func toJobResource(job model.EnrichmentJob) jobResource {
+	return jobResource{
+		Id:              job.Id,
+		ProductId:       job.ProductId,
+		Enrichment:      job.Enrichment,
+		Status:          string(job.Status),
+		Trigger:         job.Trigger,
+		Error:           job.Error,
+		CancelRequested: job.CancelRequested,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       timeOrNil(job.StartedAt),
+		FinishedAt:      timeOrNil(job.FinishedAt),
+	}
+}
+
+// handleEnqueue requests the enrichments of the products again, even if they are done, e.g.
+//
+//	POST /v1/admin/enrichments {"productIds": ["B00M49SG0Q"], "enrichments": ["relevantVideos"]}
+//	POST /v1/admin/enrichments {"filter": {"region": "DE", "createdAfter": "2024-01-01T00:00:00Z", "limit": 100}}
+func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
+	req := enqueueRequest{}
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if (len(req.ProductIds) == 0) == (req.Filter == nil) {
+		writeError(w, http.StatusBadRequest, errors.New("either productIds or filter must be set"))
+		return
+	}
+	if len(req.ProductIds) > maxEnqueuedProducts {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("at most %d productIds can be enqueued", maxEnqueuedProducts))
+		return
+	}
+	if len(req.Enrichments) == 0 {
+		req.Enrichments = enrichments
+	}
+	for _, enrichment := range req.Enrichments {
+		if !slices.Contains(enrichments, enrichment) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("enrichment must be one of %s", strings.Join(enrichments, ", ")))
+			return
+		}
+	}
+
+	ctx := r.Context()
+	productIds := req.ProductIds
+	if req.Filter != nil {
+		where, limit := req.Filter.where()
+		ids, err := s.productRepo.ListIds(ctx, where, limit)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		productIds = ids
+	}
+
+	resp := enqueueResponse{Jobs: []jobResource{}}
+	for _, productId := range productIds {
+		for _, enrichment := range req.Enrichments {
+			job, err := s.enqueue(ctx, productId, enrichment)
+			if err != nil {
+				resp.Failed = append(resp.Failed, enqueueFailure{ProductId: productId, Enrichment: enrichment, Error: enqueueError(err)})
+				continue
+			}
+			resp.Jobs = append(resp.Jobs, toJobResource(job))
+		}
+	}
+
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// enqueue records the queued job and triggers the enrichment the way a change of the product does
+func (s *Server) enqueue(ctx context.Context, productId, enrichment string) (model.EnrichmentJob, error) {
+	if _, err := s.getProduct(ctx, productId); err != nil {
+		return model.EnrichmentJob{}, err
+	}
+
+	job, err := s.jobs.Enqueue(ctx, productId, enrichment)
+	if err != nil {
+		return job, err
+	}
+
+	switch enrichment {
+	case model.EnrichmentReviewSentiments:
+		err = s.productRepo.Update(ctx, productId, model.Product{SentimentAnalized: utils.BoolToPointer(false)})
+	case model.EnrichmentRelevantVideos:
+		// the videos of a product are only created once, later searches are requested on the videos themselves
+		_, err = s.relevantVideosRepo.GetById(ctx, productId)
+		if err == nil {
+			err = s.relevantVideosRepo.RequestSearch(ctx, productId)
+		} else if errors.Is(err, ierr.NotFound) {
+			err = s.productRepo.Update(ctx, productId, model.Product{RelatedVideosAnalized: utils.BoolToPointer(false)})
+		}
+	}
+	if err != nil {
+		// the job would stay queued forever
+		s.jobs.Abandon(ctx, job, err)
+		return job, err
+	}
+	return job, nil
+}
+
+// enqueueError hides the unexpected errors like writeServiceError does
+func enqueueError(err error) string {
+	switch {
+	case errors.Is(err, ierr.NotFound), errors.Is(err, jobs.ErrRunning):
+		return err.Error()
+	default:
+		return "internal error"
+	}
+}
+
+func (f productFilter) where() ([]filter.Where, int) {
+	where := []filter.Where{}
+	if f.Region != "" {
+		where = append(where, filter.Where{Path: productRepository.RegionFieldPath, Op: ops.Equal, Value: f.Region})
+	}
+	if f.Language != "" {
+		where = append(where, filter.Where{Path: productRepository.LanguageFieldPath, Op: ops.Equal, Value: f.Language})
+	}
+	if !f.CreatedAfter.IsZero() {
+		where = append(where, filter.Where{Path: productRepository.CreatedAtFieldPath, Op: ops.GreaterEqual, Value: f.CreatedAfter})
+	}
+	if !f.CreatedBefore.IsZero() {
+		where = append(where, filter.Where{Path: productRepository.CreatedAtFieldPath, Op: ops.Smaller, Value: f.CreatedBefore})
+	}
+
+	limit := maxEnqueuedProducts
+	if f.Limit > 0 {
+		limit = min(f.Limit, maxEnqueuedProducts)
+	}
+	return where, limit
+}
+
+// handleListJobs serves a page of the job history, newest first, optionally of a single product, e.g.
+//
+//	GET /v1/admin/jobs?productId=B00M49SG0Q&pageSize=50
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	history, err := s.jobs.History(r.Context(), r.URL.Query().Get("productId"), maxJobHistory)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resources := make([]jobResource, 0, len(history))
+	for _, job := range history {
+		resources = append(resources, toJobResource(job))
+	}
+	p, err := paginate(r, resources)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handleGetJob serves a job, e.g.
+//
+//	GET /v1/admin/jobs/<jobId>
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.jobs.Get(r.Context(), r.PathValue("jobId"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toJobResource(*job))
+}
+
+// handleCancelJob cancels a queued or a running job, e.g.
+//
+//	POST /v1/admin/jobs/<jobId>/cancel
+//
+// The cancel of a job running on another worker is accepted, the job is canceled once that worker notices it.
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.jobs.Cancel(r.Context(), r.PathValue("jobId"))
+	switch {
+	case errors.Is(err, jobs.ErrFinished):
+		writeError(w, http.StatusConflict, err)
+	case err != nil:
+		writeServiceError(w, r, err)
+	case job.Status == model.JobRunning:
+		writeJSON(w, http.StatusAccepted, toJobResource(job))
+	default:
+		writeJSON(w, http.StatusOK, toJobResource(job))
+	}
+}
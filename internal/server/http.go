@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-firestore-gpt/internal/breaker"
+	ierr "go-firestore-gpt/internal/errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// the largest request body accepted
+	maxBodyBytes = 1 << 16
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+func readJSON(w http.ResponseWriter, r *http.Request, out interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeServiceError maps the error of a repository to its status. The unexpected errors are logged and not exposed.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ierr.NotFound):
+		writeError(w, http.StatusNotFound, ierr.NotFound)
+	case errors.Is(err, breaker.ErrOpen):
+		writeError(w, http.StatusServiceUnavailable, breaker.ErrOpen)
+	default:
+		log.Error().Err(err).Msgf("%s %s failed", r.Method, r.URL.Path)
+		writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+	}
+}
+
+// writeCachableJSON writes the body along with its ETag, or only the not modified status if the client already has it.
+func writeCachableJSON(w http.ResponseWriter, r *http.Request, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeServiceError(w, r, fmt.Errorf("marshal response: %w", err))
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(bytes.TrimSpace(data), '\n'))
+}
+
+// etagMatches compares the tags of an If-None-Match header weakly, as RFC 9110 requires
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// page is a page of a list resource. The next page is requested with the next page token, which is empty on the last page.
+type page[T any] struct {
+	Items         []T    `json:"items"`
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+// paginate returns the page of the items requested by the pageSize and pageToken query parameters.
+// The token is opaque to the clients, it is the offset of the page.
+func paginate[T any](r *http.Request, items []T) (page[T], error) {
+	size := defaultPageSize
+	if v := r.URL.Query().Get("pageSize"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return page[T]{}, fmt.Errorf("pageSize must be a positive number")
+		}
+		size = min(n, maxPageSize)
+	}
+
+	offset := 0
+	if token := r.URL.Query().Get("pageToken"); token != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(token)
+		n, convErr := strconv.Atoi(string(decoded))
+		if err != nil || convErr != nil || n < 0 {
+			return page[T]{}, fmt.Errorf("invalid pageToken '%s'", token)
+		}
+		offset = n
+	}
+
+	start := min(offset, len(items))
+	end := min(start+size, len(items))
+	p := page[T]{Items: append([]T{}, items[start:end]...)}
+	if end < len(items) {
+		p.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+	}
+	return p, nil
+}
+
+// bearer only serves the requests carrying the token as a bearer token
+func bearer(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	ierr "go-firestore-gpt/internal/errors"
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/utils"
+)
+
+// handleGetProduct serves the product, e.g.
+//
+//	GET /v1/products/B00M49SG0Q
+func (s *Server) handleGetProduct(w http.ResponseWriter, r *http.Request) {
+	product, err := s.getProduct(r.Context(), r.PathValue("productId"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeCachableJSON(w, r, toProductResource(*product))
+}
+
+// handleListSentiments serves a page of the review sentiments of the product, ordered by their label, e.g.
+//
+//	GET /v1/products/B00M49SG0Q/sentiments?pageSize=10&pageToken=<nextPageToken>
+func (s *Server) handleListSentiments(w http.ResponseWriter, r *http.Request) {
+	productId := r.PathValue("productId")
+	if _, err := s.reviewSentimentsRepo.GetById(r.Context(), productId); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	sentiments, err := s.reviewSentimentsRepo.GetSentiments(r.Context(), productId)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	sort.Slice(sentiments, func(i, j int) bool {
+		return sentiments[i].Label < sentiments[j].Label
+	})
+	resources := make([]sentimentResource, 0, len(sentiments))
+	for _, sentiment := range sentiments {
+		resources = append(resources, toSentimentResource(sentiment))
+	}
+
+	writePage(w, r, resources)
+}
+
+// handleListVideos serves a page of the relevant videos of the product, best ranked first.
+// The demoted videos are not served.
+//
+//	GET /v1/products/B00M49SG0Q/videos?pageSize=10&pageToken=<nextPageToken>
+func (s *Server) handleListVideos(w http.ResponseWriter, r *http.Request) {
+	productId := r.PathValue("productId")
+	if _, err := s.relevantVideosRepo.GetById(r.Context(), productId); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	videos, err := s.relevantVideosRepo.GetVideos(r.Context(), productId)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resources := make([]videoResource, 0, len(videos))
+	for _, video := range videos {
+		if !video.Demoted {
+			resources = append(resources, toVideoResource(video))
+		}
+	}
+	// the unranked videos, e.g. stored before the ranking, come last
+	sort.SliceStable(resources, func(i, j int) bool {
+		ri, rj := resources[i].Rank, resources[j].Rank
+		if (ri == 0) != (rj == 0) {
+			return rj == 0
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return resources[i].Id < resources[j].Id
+	})
+
+	writePage(w, r, resources)
+}
+
+// handleGetStatus serves whether each enrichment of the product is done, e.g.
+//
+//	GET /v1/products/B00M49SG0Q/status
+func (s *Server) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	product, err := s.getProduct(ctx, r.PathValue("productId"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	productId := utils.StringFromPointer(product.Id)
+	status := statusResource{
+		ProductId:      productId,
+		Sentiments:     enrichmentStatus{Done: product.SentimentAnalized != nil && *product.SentimentAnalized},
+		RelevantVideos: relevantVideosStatus{Done: product.RelatedVideosAnalized != nil && *product.RelatedVideosAnalized},
+	}
+
+	sentiments, err := s.reviewSentimentsRepo.GetById(ctx, productId)
+	if err != nil && !errors.Is(err, ierr.NotFound) {
+		writeServiceError(w, r, err)
+		return
+	}
+	if sentiments != nil {
+		status.Sentiments.PromptVersion = sentiments.PromptVersion
+		status.Sentiments.UpdatedAt = timeOrNil(sentiments.UpdatedAt)
+	}
+
+	videos, err := s.relevantVideosRepo.GetById(ctx, productId)
+	if err != nil && !errors.Is(err, ierr.NotFound) {
+		writeServiceError(w, r, err)
+		return
+	}
+	if videos != nil {
+		status.RelevantVideos.Ready = videos.Ready != nil && *videos.Ready
+		status.RelevantVideos.PromptVersions = videos.PromptVersions
+		status.RelevantVideos.SearchedAt = timeOrNil(videos.SearchedAt)
+		status.RelevantVideos.UpdatedAt = timeOrNil(videos.UpdatedAt)
+	}
+
+	writeCachableJSON(w, r, status)
+}
+
+func (s *Server) getProduct(ctx context.Context, productId string) (*model.Product, error) {
+	product, err := s.productRepo.GetById(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ierr.NotFound
+	}
+	return product, nil
+}
+
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	p, err := paginate(r, items)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeCachableJSON(w, r, p)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"time"
+
+	"go-firestore-gpt/internal/model"
+	"go-firestore-gpt/internal/utils"
+)
+
+// The resources are the json representation of the enrichments served by the api. They are decoupled from the
+// documents, so the collections can change without breaking the consumers.
+
+type productResource struct {
+	Id          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Reviews     int       `json:"reviews"`
+	QAs         int       `json:"qas"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type sentimentResource struct {
+	Label string `json:"label"`
+	Score int    `json:"score"`
+}
+
+type videoResource struct {
+	Id              string     `json:"id"`
+	Url             string     `json:"url"`
+	Title           string     `json:"title"`
+	Source          string     `json:"source,omitempty"`
+	ChannelTitle    string     `json:"channelTitle,omitempty"`
+	DurationSeconds int64      `json:"durationSeconds,omitempty"`
+	PublishedAt     *time.Time `json:"publishedAt,omitempty"`
+	Language        string     `json:"language,omitempty"`
+	ThumbnailUrl    string     `json:"thumbnailUrl,omitempty"`
+	Rank            int        `json:"rank,omitempty"`
+	Score           float64    `json:"score,omitempty"`
+	ThumbUp         int        `json:"thumbUp"`
+	ThumbDown       int        `json:"thumbDown"`
+}
+
+// statusResource tells whether each enrichment of the product is done
+type statusResource struct {
+	ProductId      string               `json:"productId"`
+	Sentiments     enrichmentStatus     `json:"sentiments"`
+	RelevantVideos relevantVideosStatus `json:"relevantVideos"`
+}
+
+type enrichmentStatus struct {
+	Done          bool       `json:"done"`
+	PromptVersion string     `json:"promptVersion,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+type relevantVideosStatus struct {
+	Done bool `json:"done"`
+	// false while the videos are searched again, e.g. after a demotion
+	Ready          bool              `json:"ready"`
+	PromptVersions map[string]string `json:"promptVersions,omitempty"`
+	SearchedAt     *time.Time        `json:"searchedAt,omitempty"`
+	UpdatedAt      *time.Time        `json:"updatedAt,omitempty"`
+}
+
+func toProductResource(product model.Product) productResource {
+	return productResource{
+		Id:          utils.StringFromPointer(product.Id),
+		Name:        utils.StringFromPointer(product.Name),
+		Description: utils.StringFromPointer(product.Description),
+		Region:      utils.StringFromPointer(product.Region),
+		Language:    utils.StringFromPointer(product.Language),
+		Reviews:     len(product.Reviews),
+		QAs:         len(product.QAs),
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+	}
+}
+
+func toSentimentResource(sentiment model.Sentiment) sentimentResource {
+	return sentimentResource{Label: sentiment.Label, Score: sentiment.Score}
+}
+
+func toVideoResource(video model.Video) videoResource {
+	return videoResource{
+		Id:              utils.StringFromPointer(video.Id),
+		Url:             video.Url,
+		Title:           video.Title,
+		Source:          video.Source,
+		ChannelTitle:    video.ChannelTitle,
+		DurationSeconds: video.DurationSeconds,
+		PublishedAt:     timeOrNil(video.PublishedAt),
+		Language:        video.Language,
+		ThumbnailUrl:    video.ThumbnailUrl,
+		Rank:            video.Rank,
+		Score:           video.Score,
+		ThumbUp:         video.ThumbUp,
+		ThumbDown:       video.ThumbDown,
+	}
+}
+
+// timeOrNil omits the zero times from the responses
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/jobs"
+	productRepository "go-firestore-gpt/internal/repository/product"
+	relevantVideosRepository "go-firestore-gpt/internal/repository/relevantvideos"
+	reviewSentimentsRepository "go-firestore-gpt/internal/repository/reviewsentiments"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Server serves the http api of the enrichments, e.g. to read the enrichments of a product
+// or to record the feedback of the users on the videos.
+type Server struct {
+	productRepo          productRepository.IRepository
+	reviewSentimentsRepo reviewSentimentsRepository.IRepository
+	relevantVideosRepo   relevantVideosRepository.IRepository
+	jobs                 *jobs.Tracker
+	cnf                  config.API
+	mux                  *http.ServeMux
+}
+
+func New(
+	productRepo productRepository.IRepository,
+	reviewSentimentsRepo reviewSentimentsRepository.IRepository,
+	relevantVideosRepo relevantVideosRepository.IRepository,
+	tracker *jobs.Tracker,
+	cnf config.API,
+) *Server {
+	s := &Server{
+		productRepo:          productRepo,
+		reviewSentimentsRepo: reviewSentimentsRepo,
+		relevantVideosRepo:   relevantVideosRepo,
+		jobs:                 tracker,
+		cnf:                  cnf,
+		mux:                  http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /v1/products/{productId}", s.handleGetProduct)
+	s.mux.HandleFunc("GET /v1/products/{productId}/status", s.handleGetStatus)
+	s.mux.HandleFunc("GET /v1/products/{productId}/sentiments", s.handleListSentiments)
+	s.mux.HandleFunc("GET /v1/products/{productId}/videos", s.handleListVideos)
+
+	if cnf.VoteToken != "" {
+		s.mux.HandleFunc("POST /v1/products/{productId}/videos/{videoId}/votes", bearer(cnf.VoteToken, s.handleVote))
+	}
+
+	if cnf.AdminToken != "" {
+		admin := func(next http.HandlerFunc) http.HandlerFunc { return bearer(cnf.AdminToken, next) }
+		s.mux.HandleFunc("POST /v1/admin/enrichments", admin(s.handleEnqueue))
+		s.mux.HandleFunc("GET /v1/admin/jobs", admin(s.handleListJobs))
+		s.mux.HandleFunc("GET /v1/admin/jobs/{jobId}", admin(s.handleGetJob))
+		s.mux.HandleFunc("POST /v1/admin/jobs/{jobId}/cancel", admin(s.handleCancelJob))
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Serve serves the api on the address until the context is done.
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: s, ReadHeaderTimeout: time.Second * 10}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info().Msgf("serving the api on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ctx.Err()
+}
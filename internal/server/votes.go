@@ -0,0 +1,35 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"go-firestore-gpt/internal/model"
+)
+
+type voteRequest struct {
+	Vote model.Vote `json:"vote"`
+}
+
+// handleVote counts a thumbs up or down of a user on a relevant video of the product, e.g.
+//
+//	POST /v1/products/B00M49SG0Q/videos/<videoId>/votes {"vote": "up"}
+func (s *Server) handleVote(w http.ResponseWriter, r *http.Request) {
+	req := voteRequest{}
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Vote != model.VoteUp && req.Vote != model.VoteDown {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("vote must be '%s' or '%s'", model.VoteUp, model.VoteDown))
+		return
+	}
+
+	err := s.relevantVideosRepo.Vote(r.Context(), r.PathValue("productId"), r.PathValue("videoId"), req.Vote)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
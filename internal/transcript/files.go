@@ -0,0 +1,36 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FileSource reads the transcripts from a directory of '<videoId>.vtt', '<videoId>.srt' or '<videoId>.txt' files,
+// e.g. for tests or for videos whose captions were downloaded beforehand.
+type FileSource struct {
+	dir string
+}
+
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{dir: dir}
+}
+
+func (s *FileSource) Transcript(ctx context.Context, videoId string) (string, error) {
+	for _, ext := range []string{".vtt", ".srt", ".txt"} {
+		data, err := os.ReadFile(filepath.Join(s.dir, filepath.Base(videoId)+ext))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if ext == ".txt" {
+			return string(data), nil
+		}
+		return ParseSubtitles(string(data)), nil
+	}
+	return "", ErrNotFound
+}
@@ -0,0 +1,43 @@
+package transcript
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var markupPattern = regexp.MustCompile(`<[^>]*>`)
+
+// ParseSubtitles returns the text of WebVTT or SRT subtitles without the headers, cue numbers, timings and markup.
+// The lines repeated by rolling captions are kept once.
+func ParseSubtitles(data string) string {
+	lines := []string{}
+	last := ""
+
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "",
+			strings.HasPrefix(line, "WEBVTT"),
+			strings.HasPrefix(line, "NOTE"),
+			strings.HasPrefix(line, "Kind:"),
+			strings.HasPrefix(line, "Language:"),
+			strings.Contains(line, "-->"):
+			continue
+		}
+		if _, err := strconv.Atoi(line); err == nil {
+			// cue number of srt
+			continue
+		}
+
+		line = strings.TrimSpace(markupPattern.ReplaceAllString(line, ""))
+		if line == "" || line == last {
+			continue
+		}
+		lines = append(lines, line)
+		last = line
+	}
+
+	return strings.Join(lines, " ")
+}
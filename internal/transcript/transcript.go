@@ -0,0 +1,59 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-firestore-gpt/internal/config"
+)
+
+// names of the transcript sources
+const (
+	None     = "none"
+	Captions = "captions"
+	Files    = "files"
+)
+
+var ErrNotFound = errors.New("transcript not found")
+
+// Source returns the plain text transcript of a video, or ErrNotFound if the video has none.
+type Source interface {
+	Transcript(ctx context.Context, videoId string) (string, error)
+}
+
+// New returns the configured source, or nil if the transcripts are disabled.
+// The captions source is implemented by the YouTube client.
+func New(cnf config.Transcript, captions Source) (Source, error) {
+	switch cnf.Source {
+	case None, "":
+		return nil, nil
+	case Captions:
+		if captions == nil {
+			return nil, fmt.Errorf("the captions transcript source is unavailable")
+		}
+		return captions, nil
+	case Files:
+		return NewFileSource(cnf.Dir), nil
+	}
+	return nil, fmt.Errorf("unknown transcript source '%s'", cnf.Source)
+}
+
+// Sample returns at most maxChars of the transcript, taken from its beginning, middle and end,
+// so that a long video is judged by more than its introduction.
+func Sample(text string, maxChars int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= maxChars {
+		return string(runes)
+	}
+
+	const excerpts = 3
+	size := maxChars / excerpts
+	parts := make([]string, 0, excerpts)
+	for i := 0; i < excerpts; i++ {
+		start := (len(runes) - size) * i / (excerpts - 1)
+		parts = append(parts, strings.TrimSpace(string(runes[start:start+size])))
+	}
+	return strings.Join(parts, " ... ")
+}
@@ -0,0 +1,55 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// the fixtures of the default TRANSCRIPT_DIR, named after the videos of the fake server script
+const fixturesDir = "../../testdata/transcripts"
+
+func TestFileSource(t *testing.T) {
+	tests := []struct {
+		videoId string
+		want    string
+	}{
+		{"fake-video-1", "Hi everyone, today we review the fake product. The battery lasts two days. It is well built but a bit heavy."},
+		{"fake-video-2", "This video is about something else. Thanks for watching!"},
+	}
+
+	source := NewFileSource(fixturesDir)
+	for _, tt := range tests {
+		t.Run(tt.videoId, func(t *testing.T) {
+			got, err := source.Transcript(context.Background(), tt.videoId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSourceNotFound(t *testing.T) {
+	_, err := NewFileSource(fixturesDir).Transcript(context.Background(), "unknown-video")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestSample(t *testing.T) {
+	short := "a short transcript"
+	if got := Sample("  "+short+"\n", 100); got != short {
+		t.Errorf("short transcript: got %q, want %q", got, short)
+	}
+
+	text := strings.Repeat("a", 100) + strings.Repeat("b", 100) + strings.Repeat("c", 100)
+	got := Sample(text, 30)
+	want := strings.Repeat("a", 10) + " ... " + strings.Repeat("b", 10) + " ... " + strings.Repeat("c", 10)
+	if got != want {
+		t.Errorf("long transcript: got %q, want %q", got, want)
+	}
+}
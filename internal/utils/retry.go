@@ -1,17 +1,23 @@
 package utils
 
 import (
+	"context"
 	"crypto/rand"
+	"errors"
 	"math"
 	"math/big"
 	"sync"
 	"time"
 )
 
+// the longest Retry-After honored, a longer one is capped to it
+const maxRetryAfter = time.Minute
+
 type RetryHandler struct {
 	rndMu               sync.Mutex
 	maxDelay, maxJitter time.Duration
 	maxRetry            int
+	classify            Classifier
 }
 
 func NewRetryHandler(MaxDelay, MaxJitter time.Duration, maxRetry int) *RetryHandler {
@@ -20,22 +26,66 @@ func NewRetryHandler(MaxDelay, MaxJitter time.Duration, maxRetry int) *RetryHand
 		maxDelay:  MaxDelay,
 		maxJitter: MaxJitter,
 		maxRetry:  maxRetry,
+		classify:  Classify,
 	}
 }
 
+// WithClassifier replaces the default classification of the errors.
+func (b *RetryHandler) WithClassifier(classify Classifier) *RetryHandler {
+	b.classify = classify
+	return b
+}
+
 type CallFunc func() error
 
-func (b *RetryHandler) Do(c CallFunc) {
+// Do calls c until it succeeds, returns a non-retryable error, the attempts are exhausted or the context is done.
+// It returns the last error of c, or the context error if the context is done while backing off.
+func (b *RetryHandler) Do(ctx context.Context, c CallFunc) error {
+	var err error
 	for i := 1; i < b.maxRetry+1; i++ {
-		if err := c(); err == nil {
-			return
+		if err = c(); err == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return err
+		}
+
+		retryable, retryAfter := b.classify(err)
+		if !retryable || i == b.maxRetry {
+			return err
+		}
+
+		if waitErr := b.backoff(ctx, i, retryAfter); waitErr != nil {
+			return waitErr
 		}
-		b.backoff(i)
 	}
+	return err
 }
 
-// Backoff is blocking and will return after the backoff duration.
-func (b *RetryHandler) backoff(retryCount int) {
+// Backoff is blocking and will return after the backoff duration, or the Retry-After duration
+// requested by the server, or once the context is done.
+func (b *RetryHandler) backoff(ctx context.Context, retryCount int, retryAfter time.Duration) error {
+	sleepTime := retryAfter
+	if sleepTime <= 0 {
+		sleepTime = b.backoffDuration(retryCount)
+	}
+	if sleepTime > maxRetryAfter {
+		sleepTime = maxRetryAfter
+	}
+
+	timer := time.NewTimer(sleepTime)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (b *RetryHandler) backoffDuration(retryCount int) time.Duration {
 
 	if b.maxDelay == 0 {
 		b.maxDelay = 5000 * time.Millisecond
@@ -57,7 +107,7 @@ func (b *RetryHandler) backoff(retryCount int) {
 	if sleepTime > b.maxDelay {
 		sleepTime = b.maxDelay
 	}
-	<-time.After(sleepTime)
+	return sleepTime
 }
 
 func newRnd(cap int64) int64 {
@@ -69,3 +119,29 @@ func newRnd(cap int64) int64 {
 
 	return randomInt.Int64()
 }
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks the error as non-retryable.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether the error was marked as non-retryable.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("service unavailable")
+
+func newTestRetryHandler() *RetryHandler {
+	return NewRetryHandler(time.Millisecond*10, time.Millisecond*2, 3)
+}
+
+func TestRetryDo(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error // the errors of the successive calls, the calls after them succeed
+		wantErr   error
+		wantCalls int
+	}{
+		{"succeeds at once", nil, nil, 1},
+		{"succeeds after transient errors", []error{errUnavailable, errUnavailable}, nil, 3},
+		{"attempts exhausted", []error{errUnavailable, errUnavailable, errUnavailable}, errUnavailable, 3},
+		{"permanent error", []error{Permanent(errUnavailable)}, errUnavailable, 1},
+		{"canceled call", []error{errUnavailable, context.Canceled}, context.Canceled, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := newTestRetryHandler().Do(context.Background(), func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryDoRetryAfter(t *testing.T) {
+	const wait = time.Millisecond * 100
+
+	// the server asked to wait longer than the backoff of the handler
+	handler := newTestRetryHandler().WithClassifier(func(err error) (bool, time.Duration) {
+		return true, wait
+	})
+
+	calls := 0
+	start := time.Now()
+	err := handler.Do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return errUnavailable
+		}
+		return nil
+	})
+
+	if err != nil || calls != 2 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+	if waited := time.Since(start); waited < wait {
+		t.Errorf("waited %s, want at least the Retry-After %s", waited, wait)
+	}
+}
+
+func TestRetryDoContextDone(t *testing.T) {
+	handler := newTestRetryHandler().WithClassifier(func(err error) (bool, time.Duration) {
+		return true, time.Minute
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	calls := 0
+	err := handler.Do(ctx, func() error {
+		calls++
+		return errUnavailable
+	})
+
+	// the backoff stops once the context is done
+	if !errors.Is(err, context.DeadlineExceeded) || calls != 1 {
+		t.Errorf("got %v after %d calls, want %v after 1 call", err, calls, context.DeadlineExceeded)
+	}
+}
+
+func TestBackoffDuration(t *testing.T) {
+	handler := NewRetryHandler(time.Second*5, time.Second*2, 5)
+	for retry := 1; retry <= 5; retry++ {
+		if got := handler.backoffDuration(retry); got <= 0 || got > time.Second*5 {
+			t.Errorf("retry %d: got %s, want a backoff capped to the max delay", retry, got)
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("got an error, want a nil error kept nil")
+	}
+
+	err := Permanent(errUnavailable)
+	if !IsPermanent(err) || !errors.Is(err, errUnavailable) || err.Error() != errUnavailable.Error() {
+		t.Errorf("got %v, want a permanent error wrapping %v", err, errUnavailable)
+	}
+	if IsPermanent(errUnavailable) {
+		t.Error("got a permanent error, want the unmarked error retryable")
+	}
+}
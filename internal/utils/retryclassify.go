@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Classifier decides whether an error is worth retrying and how long the server asked to wait before the retry.
+type Classifier func(err error) (retryable bool, retryAfter time.Duration)
+
+// HTTPStatusError is implemented by the errors of the http clients carrying the response status, e.g. the llm providers.
+type HTTPStatusError interface {
+	HTTPStatus() (statusCode int, header http.Header)
+}
+
+// quota reasons of the google apis which are not lifted before the next day
+var exhaustedQuotaReasons = map[string]bool{
+	"quotaExceeded":      true,
+	"dailyLimitExceeded": true,
+}
+
+// Classify is the default classifier. Errors marked as permanent, cancellations, 4xx responses except 408 and 429,
+// exhausted daily quotas and gRPC client errors are not retried. The other errors, e.g. 5xx responses, timeouts
+// and network errors, are retried.
+func Classify(err error) (bool, time.Duration) {
+	if err == nil || IsPermanent(err) {
+		return false, 0
+	}
+	if errors.Is(err, context.Canceled) {
+		return false, 0
+	}
+
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) {
+		for _, item := range gErr.Errors {
+			if exhaustedQuotaReasons[item.Reason] {
+				return false, 0
+			}
+		}
+		return retryableStatus(gErr.Code), ParseRetryAfter(gErr.Header)
+	}
+
+	var hErr HTTPStatusError
+	if errors.As(err, &hErr) {
+		statusCode, header := hErr.HTTPStatus()
+		return retryableStatus(statusCode), ParseRetryAfter(header)
+	}
+
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		return retryableCode(s.Code()), 0
+	}
+
+	return true, 0
+}
+
+func retryableStatus(statusCode int) bool {
+	switch {
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return true
+	case statusCode >= 500:
+		return true
+	}
+	return false
+}
+
+func retryableCode(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.Internal, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// ParseRetryAfter returns the duration of the Retry-After header, formatted either as seconds or as an http date.
+func ParseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
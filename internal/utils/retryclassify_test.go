@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError is an error of an http client carrying the response status, like the errors of the llm providers
+type statusError struct {
+	statusCode int
+	header     http.Header
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("status %d", e.statusCode)
+}
+
+func (e statusError) HTTPStatus() (int, http.Header) {
+	return e.statusCode, e.header
+}
+
+func retryAfter(value string) http.Header {
+	return http.Header{"Retry-After": []string{value}}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantRetryable  bool
+		wantRetryAfter time.Duration
+	}{
+		{"no error", nil, false, 0},
+		{"network error", errors.New("connection reset by peer"), true, 0},
+		{"permanent", Permanent(errors.New("invalid key")), false, 0},
+		{"wrapped permanent", fmt.Errorf("search: %w", Permanent(errors.New("invalid key"))), false, 0},
+		{"canceled", fmt.Errorf("search: %w", context.Canceled), false, 0},
+		{"timed out", context.DeadlineExceeded, true, 0},
+
+		{"http 500", statusError{statusCode: http.StatusInternalServerError}, true, 0},
+		{"http 503 with retry after", statusError{http.StatusServiceUnavailable, retryAfter("7")}, true, time.Second * 7},
+		{"http 429 with retry after", statusError{http.StatusTooManyRequests, retryAfter("2")}, true, time.Second * 2},
+		{"http 408", statusError{statusCode: http.StatusRequestTimeout}, true, 0},
+		{"http 400", statusError{statusCode: http.StatusBadRequest}, false, 0},
+		{"http 401", statusError{statusCode: http.StatusUnauthorized}, false, 0},
+		{"wrapped http 502", fmt.Errorf("complete: %w", statusError{statusCode: http.StatusBadGateway}), true, 0},
+
+		{"google 503", &googleapi.Error{Code: http.StatusServiceUnavailable, Header: retryAfter("3")}, true, time.Second * 3},
+		{"google 404", &googleapi.Error{Code: http.StatusNotFound}, false, 0},
+		{"google rate limit", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, false, 0},
+		{"google exhausted quota", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}, false, 0},
+		{"google exhausted daily limit", &googleapi.Error{Code: http.StatusTooManyRequests, Errors: []googleapi.ErrorItem{{Reason: "dailyLimitExceeded"}}}, false, 0},
+
+		{"grpc unavailable", status.Error(codes.Unavailable, "unavailable"), true, 0},
+		{"grpc aborted", status.Error(codes.Aborted, "contention"), true, 0},
+		{"grpc resource exhausted", status.Error(codes.ResourceExhausted, "quota"), true, 0},
+		{"grpc not found", status.Error(codes.NotFound, "not found"), false, 0},
+		{"grpc already exists", status.Error(codes.AlreadyExists, "exists"), false, 0},
+		{"grpc invalid argument", status.Error(codes.InvalidArgument, "invalid"), false, 0},
+		{"wrapped grpc unavailable", fmt.Errorf("save: %w", status.Error(codes.Unavailable, "unavailable")), true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryable, after := Classify(tt.err)
+			if retryable != tt.wantRetryable || after != tt.wantRetryAfter {
+				t.Errorf("got %v, %s, want %v, %s", retryable, after, tt.wantRetryable, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"missing", "", 0, 0},
+		{"seconds", "120", time.Second * 120, time.Second * 120},
+		{"zero seconds", "0", 0, 0},
+		{"negative seconds", "-5", 0, 0},
+		{"invalid", "soon", 0, 0},
+		{"future date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), time.Second * 50, time.Minute},
+		{"past date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.value != "" {
+				header.Set("Retry-After", tt.value)
+			}
+
+			got := ParseRetryAfter(header)
+			if got < tt.min || got > tt.max {
+				t.Errorf("got %s, want between %s and %s", got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -15,3 +15,11 @@ func IntToPointer(i int) *int {
 func Float32ToPointer(f float32) *float32 {
 	return &f
 }
+
+// StringFromPointer returns the pointed string, or an empty string for a nil pointer.
+func StringFromPointer(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
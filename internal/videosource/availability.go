@@ -0,0 +1,101 @@
+package videosource
+
+import (
+	"context"
+
+	"go-firestore-gpt/internal/youtube"
+)
+
+// Checker is implemented by the sources which can tell whether their videos are still available,
+// e.g. not deleted nor made private.
+type Checker interface {
+	// Available returns the availability of the videos keyed by their key
+	Available(ctx context.Context, videos []Video) (map[string]bool, error)
+}
+
+// Available checks the videos with the source. The videos of a source which cannot check them are available.
+func Available(ctx context.Context, source Source, videos []Video) (map[string]bool, error) {
+	if checker, ok := source.(Checker); ok {
+		return checker.Available(ctx, videos)
+	}
+	return allAvailable(videos), nil
+}
+
+func allAvailable(videos []Video) map[string]bool {
+	available := make(map[string]bool, len(videos))
+	for _, video := range videos {
+		available[video.Key()] = true
+	}
+	return available
+}
+
+// Available dispatches the videos to the source which found them.
+func (m *merged) Available(ctx context.Context, videos []Video) (map[string]bool, error) {
+	bySource := map[string][]Video{}
+	for _, video := range videos {
+		bySource[video.Source] = append(bySource[video.Source], video)
+	}
+
+	available := allAvailable(videos)
+	for _, source := range m.sources {
+		sourceVideos := bySource[source.Name()]
+		if len(sourceVideos) == 0 {
+			continue
+		}
+
+		checked, err := Available(ctx, source, sourceVideos)
+		if err != nil {
+			return nil, err
+		}
+		for key, ok := range checked {
+			available[key] = ok
+		}
+	}
+	return available, nil
+}
+
+// Available looks the videos up with videos.list, which does not return the deleted and the private videos.
+func (s *youtubeSource) Available(ctx context.Context, videos []Video) (map[string]bool, error) {
+	found := make([]youtube.Video, 0, len(videos))
+	for _, video := range videos {
+		found = append(found, youtube.Video{ID: video.ID, URL: video.URL})
+	}
+
+	detailed, err := s.client.Details(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[string]bool, len(detailed))
+	for _, video := range detailed {
+		available[video.ID] = video.Details != nil
+	}
+	return available, nil
+}
+
+// Available requests each video, a video which is not found is deleted or not visible anymore.
+func (s *vimeoSource) Available(ctx context.Context, videos []Video) (map[string]bool, error) {
+	available := make(map[string]bool, len(videos))
+	for _, video := range videos {
+		ok, err := s.exists(ctx, video.ID)
+		if err != nil {
+			return nil, err
+		}
+		available[video.Key()] = ok
+	}
+	return available, nil
+}
+
+// Available reports whether the videos are still in the catalog.
+func (s *catalogSource) Available(ctx context.Context, videos []Video) (map[string]bool, error) {
+	ids := make(map[string]bool, len(s.entries))
+	for _, entry := range s.entries {
+		ids[entry.ID] = true
+	}
+
+	available := make(map[string]bool, len(videos))
+	for _, video := range videos {
+		available[video.Key()] = ids[video.ID]
+	}
+	return available, nil
+}
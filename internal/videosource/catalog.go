@@ -0,0 +1,110 @@
+package videosource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go-firestore-gpt/internal/utils"
+)
+
+// CatalogEntry is a curated video of the catalog file. It is suggested for the listed products,
+// and for the products whose name or search term contains any of its keywords.
+type CatalogEntry struct {
+	ID              string    `json:"id"`
+	URL             string    `json:"url"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	ProductIds      []string  `json:"productIds"`
+	Keywords        []string  `json:"keywords"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	ChannelTitle    string    `json:"channelTitle"`
+	PublishedAt     time.Time `json:"publishedAt"`
+	Language        string    `json:"language"`
+	ThumbnailUrl    string    `json:"thumbnailUrl"`
+}
+
+type catalogSource struct {
+	entries []CatalogEntry
+}
+
+// NewCatalog loads the curated videos of a JSON file holding a list of entries.
+func NewCatalog(path string) (Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read video catalog %s: %w", path, err)
+	}
+
+	entries := []CatalogEntry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse video catalog %s: %w", path, err)
+	}
+
+	for i, entry := range entries {
+		if entry.URL == "" {
+			return nil, fmt.Errorf("video catalog %s: entry %d has no url", path, i)
+		}
+		if entry.ID == "" {
+			entries[i].ID = utils.Hash(CanonicalURL(entry.URL))[:12]
+		}
+	}
+
+	return &catalogSource{entries: entries}, nil
+}
+
+func (s *catalogSource) Name() string {
+	return Catalog
+}
+
+func (s *catalogSource) Search(ctx context.Context, query Query) ([]Video, error) {
+	text := normalize(query.ProductName + " " + query.Term)
+
+	videos := []Video{}
+	for _, entry := range s.entries {
+		if int64(len(videos)) >= query.MaxResults && query.MaxResults > 0 {
+			break
+		}
+		if entry.matches(query.ProductId, text) {
+			videos = append(videos, entry.video())
+		}
+	}
+	return videos, nil
+}
+
+func (e CatalogEntry) matches(productId, text string) bool {
+	for _, id := range e.ProductIds {
+		if id == productId {
+			return true
+		}
+	}
+	for _, keyword := range e.Keywords {
+		if keyword = normalize(keyword); keyword != "" && strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e CatalogEntry) video() Video {
+	return Video{
+		Source:      Catalog,
+		ID:          e.ID,
+		URL:         e.URL,
+		Title:       e.Title,
+		Description: e.Description,
+		Details: &Details{
+			Duration:     time.Duration(e.DurationSeconds) * time.Second,
+			ChannelTitle: e.ChannelTitle,
+			PublishedAt:  e.PublishedAt,
+			Language:     e.Language,
+			ThumbnailUrl: e.ThumbnailUrl,
+		},
+	}
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,76 @@
+package videosource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+type merged struct {
+	sources []Source
+}
+
+// Merge searches all the sources concurrently and merges their results in the order of the sources,
+// keeping the first of the videos sharing a url. A failing source is skipped, the search only fails
+// if every source failed.
+func Merge(sources ...Source) Source {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return &merged{sources: sources}
+}
+
+func (m *merged) Name() string {
+	return "merged"
+}
+
+func (m *merged) Search(ctx context.Context, query Query) ([]Video, error) {
+	results := make([][]Video, len(m.sources))
+	errs := make([]error, len(m.sources))
+
+	wg := sync.WaitGroup{}
+	for i, source := range m.sources {
+		wg.Add(1)
+		go func(i int, source Source) {
+			defer wg.Done()
+			results[i], errs[i] = source.Search(ctx, query)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("search %s: %w", source.Name(), errs[i])
+				log.Error().Err(errs[i]).Msgf("failed to search the videos of productId %s", query.ProductId)
+			}
+		}(i, source)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(m.sources) {
+		return nil, errors.Join(errs...)
+	}
+
+	return Dedup(results...), nil
+}
+
+// Dedup concatenates the lists of videos, keeping the first of the videos sharing a canonical url.
+func Dedup(lists ...[]Video) []Video {
+	seen := map[string]bool{}
+	videos := []Video{}
+	for _, list := range lists {
+		for _, video := range list {
+			key := CanonicalURL(video.URL)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			videos = append(videos, video)
+		}
+	}
+	return videos
+}
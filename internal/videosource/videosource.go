@@ -0,0 +1,138 @@
+package videosource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/youtube"
+)
+
+// names of the video sources
+const (
+	YouTube = "youtube"
+	Vimeo   = "vimeo"
+	Catalog = "catalog"
+)
+
+// Query describes the product whose videos are searched.
+type Query struct {
+	ProductId   string
+	ProductName string
+	// search term extracted from the product name
+	Term string
+	// ISO 3166-1 alpha-2 region and ISO 639-1 language of the product's market, empty for the deployment's defaults
+	Region     string
+	Language   string
+	MaxResults int64
+}
+
+// Video is a candidate video found by a source.
+type Video struct {
+	Source      string
+	ID          string // unique within the source
+	URL         string
+	Title       string
+	Description string
+	// the search query which found the video, set by the caller searching several queries
+	Query string
+	// the details are optional, not every source provides them
+	Details *Details
+}
+
+// Details are the signals of a video, e.g. to rank the relevant videos.
+type Details struct {
+	Duration     time.Duration
+	ViewCount    int64
+	LikeCount    int64
+	ChannelId    string
+	ChannelTitle string
+	PublishedAt  time.Time
+	Language     string
+	ThumbnailUrl string
+}
+
+// Key identifies the video among the videos of every source. The YouTube videos keep their id,
+// the videos of the other sources are prefixed by the source, e.g. 'vimeo:76979871'.
+func (v Video) Key() string {
+	if v.Source == "" || v.Source == YouTube {
+		return v.ID
+	}
+	return v.Source + ":" + v.ID
+}
+
+// Source searches the videos of a provider.
+type Source interface {
+	Name() string
+	Search(ctx context.Context, query Query) ([]Video, error)
+}
+
+// CanonicalURL normalizes the url of a video, so that the same video found by several sources,
+// or linked in several ways, e.g. youtu.be/<id> and youtube.com/watch?v=<id>, is kept once.
+func CanonicalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	path := strings.TrimSuffix(u.Path, "/")
+
+	switch host {
+	case "youtu.be":
+		return fmt.Sprintf("youtube.com/watch?v=%s", strings.TrimPrefix(path, "/"))
+	case "youtube.com":
+		if id := u.Query().Get("v"); id != "" {
+			return fmt.Sprintf("youtube.com/watch?v=%s", id)
+		}
+		if id, ok := strings.CutPrefix(path, "/shorts/"); ok {
+			return fmt.Sprintf("youtube.com/watch?v=%s", id)
+		}
+	case "player.vimeo.com":
+		if id, ok := strings.CutPrefix(path, "/video/"); ok {
+			return fmt.Sprintf("vimeo.com/%s", id)
+		}
+	}
+
+	return host + path
+}
+
+// New creates the configured sources merged into one. The vimeo source uses the httpClient, a nil one uses the default client.
+func New(cnf config.VideoSource, vimeoCnf config.Vimeo, youtubeClient youtube.YouTubeAPI, httpClient *http.Client) (Source, error) {
+	sources := []Source{}
+	for _, name := range cnf.Sources {
+		switch strings.TrimSpace(name) {
+		case "":
+			continue
+		case YouTube:
+			if youtubeClient == nil {
+				return nil, fmt.Errorf("the youtube video source is unavailable")
+			}
+			sources = append(sources, NewYouTube(youtubeClient))
+		case Vimeo:
+			source, err := NewVimeo(vimeoCnf, httpClient)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, source)
+		case Catalog:
+			source, err := NewCatalog(cnf.CatalogFile)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, source)
+		default:
+			return nil, fmt.Errorf("unknown video source '%s'", name)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no video source is configured")
+	}
+	return Merge(sources...), nil
+}
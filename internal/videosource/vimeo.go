@@ -0,0 +1,211 @@
+package videosource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-firestore-gpt/internal/breaker"
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+// the fields of the videos returned by the Vimeo API
+const vimeoFields = "uri,name,description,link,duration,created_time,language,pictures.sizes,user.uri,user.name,stats.plays,metadata.connections.likes.total"
+
+// HTTPError is returned when the Vimeo API responds with a non 2xx status code.
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("vimeo responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// HTTPStatus lets the retries classify the error by its status code and Retry-After header.
+func (e *HTTPError) HTTPStatus() (int, http.Header) {
+	return e.StatusCode, e.Header
+}
+
+type vimeoSource struct {
+	apiUrl      string
+	accessToken string
+	httpClient  *http.Client
+}
+
+// NewVimeo searches the public videos of Vimeo, a nil httpClient uses the default one.
+func NewVimeo(cnf config.Vimeo, httpClient *http.Client) (Source, error) {
+	if cnf.AccessToken == "" {
+		return nil, fmt.Errorf("the vimeo video source requires VIMEO_ACCESS_TOKEN")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Second * 30}
+	}
+
+	return &vimeoSource{
+		apiUrl:      strings.TrimSuffix(cnf.ApiUrl, "/"),
+		accessToken: cnf.AccessToken,
+		httpClient:  httpClient,
+	}, nil
+}
+
+func (s *vimeoSource) Name() string {
+	return Vimeo
+}
+
+type vimeoVideo struct {
+	Uri         string    `json:"uri"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Link        string    `json:"link"`
+	Duration    int64     `json:"duration"` // seconds
+	CreatedTime time.Time `json:"created_time"`
+	Language    string    `json:"language"`
+	Pictures    struct {
+		Sizes []struct {
+			Width int    `json:"width"`
+			Link  string `json:"link"`
+		} `json:"sizes"`
+	} `json:"pictures"`
+	User struct {
+		Uri  string `json:"uri"`
+		Name string `json:"name"`
+	} `json:"user"`
+	Stats struct {
+		Plays int64 `json:"plays"`
+	} `json:"stats"`
+	Metadata struct {
+		Connections struct {
+			Likes struct {
+				Total int64 `json:"total"`
+			} `json:"likes"`
+		} `json:"connections"`
+	} `json:"metadata"`
+}
+
+func (s *vimeoSource) Search(ctx context.Context, query Query) ([]Video, error) {
+	log.Debug().Msgf("Search Vimeo for %s", query.Term)
+
+	params := url.Values{}
+	params.Set("query", query.Term)
+	params.Set("per_page", strconv.FormatInt(query.MaxResults, 10))
+	params.Set("fields", vimeoFields)
+
+	response := struct {
+		Data []vimeoVideo `json:"data"`
+	}{}
+
+	// every attempt goes through the breaker, so an open breaker stops the retries
+	retryHandler := utils.NewRetryHandler(time.Second*10, time.Second*3, 3)
+	err := retryHandler.Do(ctx, func() error {
+		return breaker.Get(breaker.Vimeo).Do(func() error {
+			return s.get(ctx, "/videos?"+params.Encode(), &response)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	videos := make([]Video, 0, len(response.Data))
+	for _, item := range response.Data {
+		videos = append(videos, toVimeoVideo(item))
+	}
+	return videos, nil
+}
+
+// exists reports whether the video can still be requested.
+func (s *vimeoSource) exists(ctx context.Context, id string) (bool, error) {
+	video := struct {
+		Uri string `json:"uri"`
+	}{}
+
+	retryHandler := utils.NewRetryHandler(time.Second*10, time.Second*3, 3)
+	err := retryHandler.Do(ctx, func() error {
+		return breaker.Get(breaker.Vimeo).Do(func() error {
+			return s.get(ctx, "/videos/"+url.PathEscape(id)+"?fields=uri", &video)
+		})
+	})
+
+	httpErr := &HTTPError{}
+	if errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusNotFound || httpErr.StatusCode == http.StatusForbidden) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *vimeoSource) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiUrl+path, nil)
+	if err != nil {
+		return utils.Permanent(err)
+	}
+	req.Header.Set("Authorization", "bearer "+s.accessToken)
+	req.Header.Set("Accept", "application/vnd.vimeo.*+json;version=3.4")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &HTTPError{StatusCode: resp.StatusCode, Header: resp.Header, Body: string(data)}
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return utils.Permanent(fmt.Errorf("decode vimeo response: %w", err))
+	}
+	return nil
+}
+
+func toVimeoVideo(item vimeoVideo) Video {
+	id := strings.TrimPrefix(item.Uri, "/videos/")
+	link := item.Link
+	if link == "" {
+		link = fmt.Sprintf("https://vimeo.com/%s", id)
+	}
+
+	// the largest picture is the best thumbnail
+	thumbnail, width := "", 0
+	for _, size := range item.Pictures.Sizes {
+		if size.Width > width {
+			thumbnail, width = size.Link, size.Width
+		}
+	}
+
+	return Video{
+		Source:      Vimeo,
+		ID:          id,
+		URL:         link,
+		Title:       item.Name,
+		Description: item.Description,
+		Details: &Details{
+			Duration:     time.Duration(item.Duration) * time.Second,
+			ViewCount:    item.Stats.Plays,
+			LikeCount:    item.Metadata.Connections.Likes.Total,
+			ChannelId:    strings.TrimPrefix(item.User.Uri, "/users/"),
+			ChannelTitle: item.User.Name,
+			PublishedAt:  item.CreatedTime,
+			Language:     item.Language,
+			ThumbnailUrl: thumbnail,
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package videosource
+
+import (
+	"context"
+	"errors"
+
+	"go-firestore-gpt/internal/youtube"
+
+	"github.com/rs/zerolog/log"
+)
+
+type youtubeSource struct {
+	client youtube.YouTubeAPI
+}
+
+// NewYouTube searches YouTube and fetches the details of the found videos.
+func NewYouTube(client youtube.YouTubeAPI) Source {
+	return &youtubeSource{client: client}
+}
+
+func (s *youtubeSource) Name() string {
+	return YouTube
+}
+
+func (s *youtubeSource) Search(ctx context.Context, query Query) ([]Video, error) {
+	found, err := s.client.Search(ctx, query.Term, query.MaxResults, youtube.SearchOptions{
+		RegionCode:        query.Region,
+		RelevanceLanguage: query.Language,
+	})
+	if err != nil {
+		if errors.Is(err, youtube.ErrNoResponse) {
+			return []Video{}, nil
+		}
+		return nil, err
+	}
+
+	// the details are optional, so the videos are still evaluated without them
+	found, err = s.client.Details(ctx, found)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to fetch the details of the videos of productId %s", query.ProductId)
+	}
+
+	videos := make([]Video, 0, len(found))
+	for _, v := range found {
+		video := Video{
+			Source:      YouTube,
+			ID:          v.ID,
+			URL:         v.URL,
+			Title:       v.Title,
+			Description: v.Description,
+		}
+		if d := v.Details; d != nil {
+			video.Details = &Details{
+				Duration:     d.Duration,
+				ViewCount:    d.ViewCount,
+				LikeCount:    d.LikeCount,
+				ChannelId:    d.ChannelId,
+				ChannelTitle: d.ChannelTitle,
+				PublishedAt:  d.PublishedAt,
+				Language:     d.Language,
+				ThumbnailUrl: d.ThumbnailUrl,
+			}
+		}
+		videos = append(videos, video)
+	}
+	return videos, nil
+}
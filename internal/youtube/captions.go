@@ -0,0 +1,107 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"go-firestore-gpt/internal/breaker"
+	"go-firestore-gpt/internal/transcript"
+	"go-firestore-gpt/internal/utils"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+// quota units consumed by a captions.list and a captions.download call
+const (
+	captionsListQuotaCost     = 50
+	captionsDownloadQuotaCost = 200
+)
+
+// the videos of the other sources are keyed differently, e.g. 'vimeo:76979871', so they have no captions here
+var videoIdPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// Transcript downloads the caption track of the video, preferring the tracks written by a person over the
+// automatic ones, and the tracks of the configured languages. Note that downloading captions requires
+// OAuth credentials which are allowed to download the tracks, an api key is not enough.
+func (c *YouTubeClient) Transcript(ctx context.Context, videoId string) (string, error) {
+	if !videoIdPattern.MatchString(videoId) {
+		return "", transcript.ErrNotFound
+	}
+
+	var tracks *youtube.CaptionListResponse
+	err := c.callYouTube(ctx, "captions.list", captionsListQuotaCost, func() error {
+		var err error
+		tracks, err = c.Service.Captions.List([]string{"snippet"}, videoId).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("list captions: %w, id: %s", err, videoId)
+	}
+
+	track := c.selectTrack(tracks.Items)
+	if track == nil {
+		return "", transcript.ErrNotFound
+	}
+
+	log.Debug().Msgf("Download the %s captions of YouTube video %s", track.Snippet.Language, videoId)
+	var data []byte
+	err = c.callYouTube(ctx, "captions.download", captionsDownloadQuotaCost, func() error {
+		resp, err := c.Service.Captions.Download(track.Id).Tfmt("vtt").Context(ctx).Download()
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		data, err = io.ReadAll(resp.Body)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("download captions: %w, id: %s", err, videoId)
+	}
+
+	return transcript.ParseSubtitles(string(data)), nil
+}
+
+func (c *YouTubeClient) selectTrack(tracks []*youtube.Caption) *youtube.Caption {
+	var best *youtube.Caption
+	bestRank := -1
+
+	for _, track := range tracks {
+		if track.Snippet == nil {
+			continue
+		}
+
+		rank := 0
+		if track.Snippet.TrackKind != "asr" {
+			rank += 1
+		}
+		for i, language := range c.captionLanguages {
+			if track.Snippet.Language == language {
+				rank += 2 * (len(c.captionLanguages) - i)
+				break
+			}
+		}
+
+		if rank > bestRank {
+			best, bestRank = track, rank
+		}
+	}
+	return best
+}
+
+// callYouTube retries the transient failures of the call. Like the calls of the llm providers, every attempt goes
+// through the YouTube circuit breaker, so the breaker counts the failed attempts and an open breaker stops the retries.
+// The quota units of the call are reserved before every attempt, since the failed attempts are charged too.
+func (c *YouTubeClient) callYouTube(ctx context.Context, name string, units int, call func() error) error {
+	retryHandler := utils.NewRetryHandler(time.Second*10, time.Second*3, 3)
+	return retryHandler.Do(ctx, func() error {
+		if err := c.reserve(ctx, name, units); err != nil {
+			return utils.Permanent(err)
+		}
+		return breaker.Get(breaker.YouTube).Do(call)
+	})
+}
@@ -0,0 +1,145 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+// the most ids accepted by a videos.list call
+const maxVideosPerCall = 50
+
+// Details are the signals of a video fetched by videos.list
+type Details struct {
+	Duration     time.Duration
+	ViewCount    int64
+	LikeCount    int64
+	ChannelId    string
+	ChannelTitle string
+	PublishedAt  time.Time
+	// the audio language, or the language of the title and description if it is unknown
+	Language     string
+	ThumbnailUrl string
+}
+
+func (c *YouTubeClient) Details(ctx context.Context, videos []Video) ([]Video, error) {
+	detailed := make([]Video, len(videos))
+	copy(detailed, videos)
+
+	index := make(map[string][]int, len(videos))
+	ids := make([]string, 0, len(videos))
+	for i, v := range videos {
+		if _, ok := index[v.ID]; !ok {
+			ids = append(ids, v.ID)
+		}
+		index[v.ID] = append(index[v.ID], i)
+	}
+
+	for start := 0; start < len(ids); start += maxVideosPerCall {
+		end := start + maxVideosPerCall
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		items, err := c.listVideos(ctx, ids[start:end])
+		if err != nil {
+			return detailed, err
+		}
+
+		for _, item := range items {
+			details := toDetails(item)
+			for _, i := range index[item.Id] {
+				detailed[i].Details = &details
+			}
+		}
+	}
+
+	return detailed, nil
+}
+
+func (c *YouTubeClient) listVideos(ctx context.Context, ids []string) ([]*youtube.Video, error) {
+	log.Debug().Msgf("Fetch the details of %d YouTube videos", len(ids))
+	call := c.Service.Videos.List([]string{"snippet,contentDetails,statistics"}).Id(ids...)
+
+	var response *youtube.VideoListResponse
+
+	err := c.callYouTube(ctx, "videos.list", videosQuotaCost, func() error {
+		var err error
+		response, err = call.Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list youtube videos: %w", err)
+	}
+
+	return response.Items, nil
+}
+
+func toDetails(item *youtube.Video) Details {
+	details := Details{}
+
+	if item.Snippet != nil {
+		details.ChannelId = item.Snippet.ChannelId
+		details.ChannelTitle = item.Snippet.ChannelTitle
+		details.Language = item.Snippet.DefaultAudioLanguage
+		if details.Language == "" {
+			details.Language = item.Snippet.DefaultLanguage
+		}
+		if publishedAt, err := time.Parse(time.RFC3339, item.Snippet.PublishedAt); err == nil {
+			details.PublishedAt = publishedAt
+		}
+		details.ThumbnailUrl = bestThumbnail(item.Snippet.Thumbnails)
+	}
+
+	if item.ContentDetails != nil {
+		details.Duration = parseISODuration(item.ContentDetails.Duration)
+	}
+
+	if item.Statistics != nil {
+		details.ViewCount = int64(item.Statistics.ViewCount)
+		details.LikeCount = int64(item.Statistics.LikeCount)
+	}
+
+	return details
+}
+
+func bestThumbnail(thumbnails *youtube.ThumbnailDetails) string {
+	if thumbnails == nil {
+		return ""
+	}
+
+	for _, t := range []*youtube.Thumbnail{thumbnails.Maxres, thumbnails.Standard, thumbnails.High, thumbnails.Medium, thumbnails.Default} {
+		if t != nil && t.Url != "" {
+			return t.Url
+		}
+	}
+	return ""
+}
+
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseISODuration parses the ISO 8601 durations of the videos, e.g. PT1H2M10S. It returns zero if the duration is malformed.
+func parseISODuration(s string) time.Duration {
+	match := isoDurationPattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour * 24, time.Hour, time.Minute, time.Second} {
+		if match[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return 0
+		}
+		d += time.Duration(n) * unit
+	}
+	return d
+}
@@ -0,0 +1,170 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-firestore-gpt/internal/metrics"
+	youtubeQuotaRepository "go-firestore-gpt/internal/repository/youtubequota"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	quotaFlushInterval = time.Minute
+	quotaCheckInterval = time.Minute
+)
+
+var ErrQuotaExceeded = errors.New("daily YouTube quota is exceeded")
+
+// the daily quota resets at midnight Pacific time
+var quotaLocation = loadQuotaLocation()
+
+func loadQuotaLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return time.FixedZone("PST", -8*60*60)
+	}
+	return loc
+}
+
+func quotaDay(t time.Time) string {
+	return t.In(quotaLocation).Format("2006-01-02")
+}
+
+// QuotaTracker accounts the quota units spent by the YouTube calls per day and periodically persists them,
+// so that the units spent by every worker and before a restart count towards the daily cap. Once the cap is
+// reached, the calls are paused until the quota resets. A nil tracker, or a zero cap, tracks nothing.
+type QuotaTracker struct {
+	repo        youtubeQuotaRepository.IRepository
+	unitsPerDay int
+
+	mu      sync.Mutex
+	day     string
+	used    int            // units of the day, both persisted and pending
+	pending map[string]int // units not persisted yet, keyed by day
+}
+
+func NewQuotaTracker(repo youtubeQuotaRepository.IRepository, unitsPerDay int) *QuotaTracker {
+	return &QuotaTracker{
+		repo:        repo,
+		unitsPerDay: unitsPerDay,
+		day:         quotaDay(time.Now()),
+		pending:     make(map[string]int),
+	}
+}
+
+// Start loads the units spent today and persists the spent units until the context is done.
+func (t *QuotaTracker) Start(ctx context.Context) error {
+	t.refresh(ctx)
+
+	ticker := time.NewTicker(quotaFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the root context is already cancelled, so give the last flush its own deadline
+			flushCtx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			t.flush(flushCtx)
+			cancel()
+			return ctx.Err()
+		case <-ticker.C:
+			t.flush(ctx)
+			t.refresh(ctx)
+		}
+	}
+}
+
+// Reserve accounts the units of a call before it is made. It blocks while the call would exceed the daily cap.
+func (t *QuotaTracker) Reserve(ctx context.Context, call string, units int) error {
+	if t == nil {
+		return nil
+	}
+
+	if !t.tryReserve(units) {
+		log.Warn().Msgf("daily YouTube quota of %d units is exceeded, %s calls are paused", t.unitsPerDay, call)
+		for !t.tryReserve(units) {
+			select {
+			case <-ctx.Done():
+				return errors.Join(ErrQuotaExceeded, ctx.Err())
+			case <-time.After(quotaCheckInterval):
+			}
+		}
+	}
+
+	metrics.YouTubeQuotaUnits.Add(call, int64(units))
+	return nil
+}
+
+// Used returns the units spent today.
+func (t *QuotaTracker) Used() int {
+	if t == nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollDay()
+	return t.used
+}
+
+func (t *QuotaTracker) tryReserve(units int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollDay()
+	if t.unitsPerDay > 0 && t.used+units > t.unitsPerDay {
+		return false
+	}
+	t.used += units
+	t.pending[t.day] += units
+	return true
+}
+
+func (t *QuotaTracker) rollDay() {
+	if day := quotaDay(time.Now()); day != t.day {
+		t.day = day
+		t.used = t.pending[day]
+	}
+}
+
+func (t *QuotaTracker) flush(ctx context.Context) {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[string]int)
+	t.mu.Unlock()
+
+	for day, units := range pending {
+		if units == 0 {
+			continue
+		}
+		if err := t.repo.Increment(ctx, day, units); err != nil {
+			log.Error().Err(err).Msg("failed to persist the youtube quota")
+			// keep the units, so the next flush persists them
+			t.mu.Lock()
+			t.pending[day] += units
+			t.mu.Unlock()
+		}
+	}
+}
+
+// refresh reloads the units spent today, including the units spent by the other workers.
+func (t *QuotaTracker) refresh(ctx context.Context) {
+	day := quotaDay(time.Now())
+	stored, err := t.repo.UnitsOfDay(ctx, day)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load the youtube quota")
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollDay()
+	if t.day == day {
+		t.used = stored + t.pending[day]
+	}
+}
@@ -0,0 +1,165 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/utils"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+// values of the safeSearch parameter
+var safeSearchValues = map[string]bool{"none": true, "moderate": true, "strict": true}
+
+// SearchOptions narrow a search to the market of a product. The empty options use the defaults of the config.
+type SearchOptions struct {
+	// ISO 3166-1 alpha-2 code, e.g. DE
+	RegionCode string
+	// ISO 639-1 code, e.g. de
+	RelevanceLanguage string
+}
+
+// searchSettings are the settings of the deployment applied to every search.
+type searchSettings struct {
+	defaults        SearchOptions
+	safeSearch      string
+	publishedWithin time.Duration
+	allowedChannels map[string]bool
+	deniedChannels  map[string]bool
+}
+
+func newSearchSettings(cnf config.Youtube) (searchSettings, error) {
+	safeSearch := strings.ToLower(strings.TrimSpace(cnf.SafeSearch))
+	if safeSearch != "" && !safeSearchValues[safeSearch] {
+		return searchSettings{}, fmt.Errorf("safe search '%s' must be one of none, moderate or strict", cnf.SafeSearch)
+	}
+
+	return searchSettings{
+		defaults: SearchOptions{
+			RegionCode:        strings.TrimSpace(cnf.RegionCode),
+			RelevanceLanguage: strings.TrimSpace(cnf.RelevanceLanguage),
+		},
+		safeSearch:      safeSearch,
+		publishedWithin: cnf.PublishedWithin,
+		allowedChannels: toSet(cnf.AllowedChannels),
+		deniedChannels:  toSet(cnf.DeniedChannels),
+	}, nil
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+func (s searchSettings) withDefaults(opts SearchOptions) SearchOptions {
+	if opts.RegionCode == "" {
+		opts.RegionCode = s.defaults.RegionCode
+	}
+	if opts.RelevanceLanguage == "" {
+		opts.RelevanceLanguage = s.defaults.RelevanceLanguage
+	}
+	return opts
+}
+
+// allowed reports whether the videos of the channel are kept. The search.list call filters by one channel
+// at most, so the lists are applied to its results.
+func (s searchSettings) allowed(channelId string) bool {
+	if s.deniedChannels[channelId] {
+		return false
+	}
+	return len(s.allowedChannels) == 0 || s.allowedChannels[channelId]
+}
+
+func (s searchSettings) filter(videos []Video) []Video {
+	if len(s.allowedChannels) == 0 && len(s.deniedChannels) == 0 {
+		return videos
+	}
+
+	kept := make([]Video, 0, len(videos))
+	for _, v := range videos {
+		if s.allowed(v.ChannelId) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func (c *YouTubeClient) Search(ctx context.Context, term string, maxResult int64, opts SearchOptions) ([]Video, error) {
+	opts = c.search.withDefaults(opts)
+	cacheKey := searchCacheKey(term, maxResult, opts, c.search)
+
+	videos, ok := c.searchCache.get(ctx, cacheKey, term)
+	if !ok {
+		var err error
+		videos, err = c.searchYouTube(ctx, term, maxResult, opts)
+		if err != nil {
+			return nil, err
+		}
+		// the results are cached before they are filtered, so a change of the channel lists applies at once
+		c.searchCache.set(ctx, cacheKey, videos)
+	}
+
+	videos = c.search.filter(videos)
+	if len(videos) == 0 {
+		// searching again returns the same empty result
+		return nil, utils.Permanent(ErrNoResponse)
+	}
+	return videos, nil
+}
+
+func (c *YouTubeClient) searchYouTube(ctx context.Context, term string, maxResult int64, opts SearchOptions) ([]Video, error) {
+	log.Debug().Msgf("Search YouTube for %s, region: '%s', language: '%s'", term, opts.RegionCode, opts.RelevanceLanguage)
+	call := c.Service.Search.List([]string{"id,snippet"}).
+		Type("video").
+		Q(term).
+		MaxResults(maxResult)
+
+	if c.search.safeSearch != "" {
+		call = call.SafeSearch(c.search.safeSearch)
+	}
+	if opts.RegionCode != "" {
+		call = call.RegionCode(opts.RegionCode)
+	}
+	if opts.RelevanceLanguage != "" {
+		call = call.RelevanceLanguage(opts.RelevanceLanguage)
+	}
+	if c.search.publishedWithin > 0 {
+		call = call.PublishedAfter(time.Now().Add(-c.search.publishedWithin).UTC().Format(time.RFC3339))
+	}
+
+	var response *youtube.SearchListResponse
+
+	err := c.callYouTube(ctx, "search.list", searchQuotaCost, func() error {
+		var err error
+		response, err = call.Context(ctx).Do()
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	videos := make([]Video, 0, len(response.Items))
+	for _, item := range response.Items {
+		video := Video{
+			ID:          item.Id.VideoId,
+			URL:         fmt.Sprintf("https://www.youtube.com/watch?v=%s", item.Id.VideoId),
+			Title:       item.Snippet.Title,
+			Description: item.Snippet.Description,
+			ChannelId:   item.Snippet.ChannelId,
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, nil
+}
@@ -0,0 +1,80 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-firestore-gpt/internal/gpt/cache"
+	"go-firestore-gpt/internal/metrics"
+	"go-firestore-gpt/internal/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+// searchCache keeps the search results by their normalized search term, so that the products sharing a name
+// do not spend the quota of a search again. Failures of the store are logged and never fail the search.
+type searchCache struct {
+	store cache.Store
+	ttl   time.Duration
+}
+
+func newSearchCache(store cache.Store, ttl time.Duration) *searchCache {
+	if store == nil {
+		return nil
+	}
+	return &searchCache{store: store, ttl: ttl}
+}
+
+func (c *searchCache) get(ctx context.Context, key, term string) ([]Video, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	value, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to read youtube search cache")
+		return nil, false
+	}
+	if !ok {
+		metrics.YouTubeSearchCache.Add("miss", 1)
+		return nil, false
+	}
+
+	videos := []Video{}
+	if err := json.Unmarshal([]byte(value), &videos); err != nil {
+		return nil, false
+	}
+
+	metrics.YouTubeSearchCache.Add("hit", 1)
+	log.Debug().Msgf("youtube search cache hit for %s", term)
+	return videos, true
+}
+
+func (c *searchCache) set(ctx context.Context, key string, videos []Video) {
+	if c == nil {
+		return
+	}
+
+	value, err := json.Marshal(videos)
+	if err != nil {
+		return
+	}
+	if err := c.store.Set(ctx, key, string(value), c.ttl); err != nil {
+		log.Error().Err(err).Msg("failed to write youtube search cache")
+	}
+}
+
+// NormalizeSearchTerm lowercases the term and collapses its whitespaces, so that the terms differing only
+// in case or spacing share their results.
+func NormalizeSearchTerm(term string) string {
+	return strings.Join(strings.Fields(strings.ToLower(term)), " ")
+}
+
+// searchCacheKey hashes the normalized term along with every parameter of the search.
+func searchCacheKey(term string, maxResult int64, opts SearchOptions, settings searchSettings) string {
+	return utils.Hash(fmt.Sprintf("youtube-search\n%s\n%d\n%s\n%s\n%s\n%s", NormalizeSearchTerm(term), maxResult,
+		strings.ToUpper(opts.RegionCode), strings.ToLower(opts.RelevanceLanguage), settings.safeSearch, settings.publishedWithin))
+}
@@ -3,11 +3,13 @@ package youtube
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	"go-firestore-gpt/internal/config"
-	"go-firestore-gpt/internal/utils"
+	"go-firestore-gpt/internal/gpt/cache"
+	"go-firestore-gpt/internal/ratelimit"
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/api/option"
@@ -16,19 +18,37 @@ import (
 
 var ErrNoResponse error = fmt.Errorf("YouTube API returned no response")
 
+// quota units consumed by a search.list and a videos.list call
+const (
+	searchQuotaCost = 100
+	videosQuotaCost = 1
+)
+
 type Video struct {
 	ID          string
 	URL         string
 	Title       string
 	Description string
+	ChannelId   string
+	// the details are only set by Details
+	Details *Details
 }
 
 type YouTubeAPI interface {
-	Search(term string, maxResult int64) ([]Video, error)
+	Search(ctx context.Context, term string, maxResult int64, opts SearchOptions) ([]Video, error)
+	// Details fetches the details of the videos. The videos whose details are not found are returned unchanged.
+	Details(ctx context.Context, videos []Video) ([]Video, error)
 }
 
 type YouTubeClient struct {
 	Service *youtube.Service
+	// paces the calls over the day, while the tracker enforces the daily cap across the workers
+	quota       *ratelimit.Limiter
+	tracker     *QuotaTracker
+	search      searchSettings
+	searchCache *searchCache
+	// preferred languages of the caption tracks, in order
+	captionLanguages []string
 }
 
 var (
@@ -37,54 +57,68 @@ var (
 	youtubeScopes = []string{youtube.YoutubeReadonlyScope}
 )
 
-func NewYouTubeClient(ctx context.Context, cnf config.Youtube) *YouTubeClient {
+// NewYouTubeClient creates the client, a nil httpClient uses the default one.
+// A custom httpClient allows recording or replaying the interactions.
+// The search results are cached in the store, a nil store disables the cache.
+func NewYouTubeClient(ctx context.Context, cnf config.Youtube, httpClient *http.Client, tracker *QuotaTracker, store cache.Store) *YouTubeClient {
 	once.Do(func() {
-		service, err := youtube.NewService(ctx, option.WithAPIKey(cnf.ApiKey), option.WithScopes(youtubeScopes...))
+		opts := []option.ClientOption{option.WithAPIKey(cnf.ApiKey), option.WithScopes(youtubeScopes...)}
+		if httpClient != nil {
+			// a custom client takes precedence over the api key option, so its transport sets the key
+			opts = []option.ClientOption{option.WithHTTPClient(&http.Client{
+				Transport: &apiKeyTransport{key: cnf.ApiKey, next: httpClient.Transport},
+				Timeout:   httpClient.Timeout,
+			})}
+		}
+		if cnf.ApiUrl != "" {
+			opts = append(opts, option.WithEndpoint(cnf.ApiUrl))
+		}
+
+		settings, err := newSearchSettings(cnf)
 		if err != nil {
-			log.Error().Err(err).Msg("Failed to create YouTube service")
+			log.Error().Err(err).Msg("Invalid YouTube search settings")
 			return
 		}
-		instance = &YouTubeClient{Service: service}
-	})
-	return instance
-}
-
-func (c *YouTubeClient) Search(term string, maxResult int64) ([]Video, error) {
-	log.Debug().Msgf("Search YouTube for %s", term)
-	call := c.Service.Search.List([]string{"id,snippet"}).
-		Type("video").
-		Q(term).
-		MaxResults(maxResult)
-
-	var response *youtube.SearchListResponse
-	var err error
 
-	retryHandler := utils.NewRetryHandler(time.Second*10, time.Second*3, 3)
-	retryHandler.Do(func() error {
-		response, err = call.Do()
+		service, err := youtube.NewService(ctx, opts...)
 		if err != nil {
-			return err
+			log.Error().Err(err).Msg("Failed to create YouTube service")
+			return
 		}
-		if len(response.Items) == 0 {
-			err = ErrNoResponse
+		instance = &YouTubeClient{
+			Service:          service,
+			search:           settings,
+			quota:            ratelimit.New(cnf.QuotaUnitsPerDay, time.Hour*24),
+			tracker:          tracker,
+			searchCache:      newSearchCache(store, cnf.SearchCacheTTL),
+			captionLanguages: cnf.CaptionLanguages,
 		}
-		return err
 	})
+	return instance
+}
 
-	if err != nil {
-		return nil, err
+// reserve accounts the quota units of a call towards the daily cap and paces the calls.
+func (c *YouTubeClient) reserve(ctx context.Context, call string, units int) error {
+	if err := c.tracker.Reserve(ctx, call, units); err != nil {
+		return err
 	}
+	return c.quota.Wait(ctx, units)
+}
 
-	videos := make([]Video, 0, len(response.Items))
-	for _, item := range response.Items {
-		video := Video{
-			ID:          item.Id.VideoId,
-			URL:         fmt.Sprintf("https://www.youtube.com/watch?v=%s", item.Id.VideoId),
-			Title:       item.Snippet.Title,
-			Description: item.Snippet.Description,
-		}
-		videos = append(videos, video)
+type apiKeyTransport struct {
+	key  string
+	next http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
 	}
 
-	return videos, nil
+	keyed := req.Clone(req.Context())
+	query := keyed.URL.Query()
+	query.Set("key", t.key)
+	keyed.URL.RawQuery = query.Encode()
+	return next.RoundTrip(keyed)
 }
@@ -6,20 +6,41 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"go-firestore-gpt/internal/breaker"
+	"go-firestore-gpt/internal/cassette"
 	"go-firestore-gpt/internal/config"
 	"go-firestore-gpt/internal/database"
+	"go-firestore-gpt/internal/experiment"
 	relevantVideoHandler "go-firestore-gpt/internal/handler/relevantvideos"
 	reviewSentimentHandler "go-firestore-gpt/internal/handler/reviewsentiment"
+	videoFeedbackJob "go-firestore-gpt/internal/handler/videofeedback"
+	videoRefreshJob "go-firestore-gpt/internal/handler/videorefresh"
+	"go-firestore-gpt/internal/jobs"
+	"go-firestore-gpt/internal/metrics"
+	"go-firestore-gpt/internal/prompt"
+	enrichmentJobsRepository "go-firestore-gpt/internal/repository/enrichmentjobs"
+	experimentsRepository "go-firestore-gpt/internal/repository/experiments"
+	llmCacheRepository "go-firestore-gpt/internal/repository/llmcache"
+	llmUsageRepository "go-firestore-gpt/internal/repository/llmusage"
 	productRepository "go-firestore-gpt/internal/repository/product"
 	relevantVideoRepository "go-firestore-gpt/internal/repository/relevantvideos"
 	reviewSentimentsRepository "go-firestore-gpt/internal/repository/reviewsentiments"
+	youtubeQuotaRepository "go-firestore-gpt/internal/repository/youtubequota"
+	"go-firestore-gpt/internal/rpc"
+	"go-firestore-gpt/internal/server"
+	"go-firestore-gpt/internal/transcript"
 	"go-firestore-gpt/internal/utils"
+	"go-firestore-gpt/internal/videosource"
 	youtubeApi "go-firestore-gpt/internal/youtube"
 
 	gpt "go-firestore-gpt/internal/gpt"
+	"go-firestore-gpt/internal/gpt/cache"
+	"go-firestore-gpt/internal/gpt/provider"
+	"go-firestore-gpt/internal/gpt/usage"
 	gptutils "go-firestore-gpt/internal/gpt/utils"
 
 	Firestore "firebase.google.com/go/v4"
@@ -40,6 +61,9 @@ func main() {
 	defer close(sigs)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
+	breaker.Configure(cnf.CircuitBreaker)
+	metrics.RegisterHealthCheck("circuit-breakers", breaker.Check)
+
 	app := createFirestoreAppOrPanic(ctx, cnf.Firebase)
 	firestoreClient := createFirestoreClientOrPanic(ctx, app)
 	defer firestoreClient.Close()
@@ -49,29 +73,63 @@ func main() {
 		panic(err)
 	}
 
-	gptFactory, err := gpt.NewClientFactory(gpt.ClientConfig{
-		ApiUrl:      cnf.GilasAI.ApiUrl,
-		ApiKey:      cnf.GilasAI.ApiKey,
-		Model:       cnf.GilasAI.Model,
-		Temperature: utils.Float32ToPointer(0.1),
-	})
-
+	meter, err := usage.NewMeter(llmUsageRepository.New(&firestoreClient), tokenizer, cnf.LLMUsage)
 	if err != nil {
 		panic(err)
 	}
 
+	llmCacheRepo := llmCacheRepository.New(&firestoreClient)
+	llmHTTPClient := cassette.NewHTTPClientOrPanic(cnf.HTTPCassette, "llm", cnf.LLM.RequestTimeout)
+	providers := provider.NewRegistry(cnf, llmHTTPClient, createProviderMiddlewaresOrPanic(cnf, llmCacheRepo, meter, tokenizer)...)
+	sentimentGptFactory := createGptFactoryOrPanic(cnf, providers, cnf.LLM.SentimentProvider)
+	videoGptFactory := createGptFactoryOrPanic(cnf, providers, cnf.LLM.VideosProvider)
+
 	productRepo := productRepository.New(&firestoreClient)
 	reviewSentimentRepo := reviewSentimentsRepository.New(&firestoreClient)
 	relevantVideoRepo := relevantVideoRepository.New(&firestoreClient)
-	youtubeClient := youtubeApi.NewYouTubeClient(ctx, cnf.Youtube)
+	jobTracker := jobs.NewTracker(enrichmentJobsRepository.New(&firestoreClient))
+	quotaTracker := youtubeApi.NewQuotaTracker(youtubeQuotaRepository.New(&firestoreClient), cnf.Youtube.QuotaUnitsPerDay)
+	searchCache, err := cache.NewStore(config.LLMCache{Store: cnf.Youtube.SearchCacheStore, Dir: cnf.Youtube.SearchCacheDir}, llmCacheRepo)
+	if err != nil {
+		panic(err)
+	}
+	youtubeClient := youtubeApi.NewYouTubeClient(ctx, cnf.Youtube, cassette.NewHTTPClientOrPanic(cnf.HTTPCassette, "youtube", 0), quotaTracker, searchCache)
 	if youtubeClient == nil {
 		panic(fmt.Errorf("failed to create a youtube client"))
 	}
 	productSentimentPublisher := productEventPublisher.ProductPublisherFactory(productRepo).OnProductReviewSentimentAnalysis()
 	productVideoPublisher := productEventPublisher.ProductPublisherFactory(productRepo).OnProductVideoAnalysis()
 
-	rv := relevantVideoHandler.New(productVideoPublisher, relevantVideoRepo, gptFactory, youtubeClient)
-	rs := reviewSentimentHandler.New(productSentimentPublisher, productRepo, reviewSentimentRepo, gptFactory, tokenizer)
+	prompts, err := prompt.New(cnf.Prompt)
+	if err != nil {
+		panic(err)
+	}
+
+	exp, err := experiment.New(cnf.Experiment, providers, gptClientConfig(), experimentsRepository.New(&firestoreClient))
+	if err != nil {
+		panic(err)
+	}
+
+	videoSource, err := videosource.New(cnf.VideoSource, cnf.Vimeo, youtubeClient, cassette.NewHTTPClientOrPanic(cnf.HTTPCassette, "vimeo", 0))
+	if err != nil {
+		panic(err)
+	}
+
+	videoGate := breaker.NewGate(
+		llmBreakers(cnf, cnf.LLM.VideosProvider),
+		videoSourceBreakers(cnf),
+		[]*breaker.Breaker{breaker.Get(breaker.Database)})
+	sentimentGate := breaker.NewGate(
+		llmBreakers(cnf, cnf.LLM.SentimentProvider),
+		[]*breaker.Breaker{breaker.Get(breaker.Database)})
+
+	transcripts, err := transcript.New(cnf.Transcript, youtubeClient)
+	if err != nil {
+		panic(err)
+	}
+
+	rv := relevantVideoHandler.New(productVideoPublisher, relevantVideoRepo, videoGptFactory, videoSource, transcripts, prompts, exp, videoGate, jobTracker, cnf.RelevantVideos)
+	rs := reviewSentimentHandler.New(productSentimentPublisher, productRepo, reviewSentimentRepo, sentimentGptFactory, tokenizer, prompts, exp, sentimentGate, jobTracker)
 
 	group, gctx := errgroup.WithContext(ctx)
 	group.Go(func() error {
@@ -86,6 +144,36 @@ func main() {
 	group.Go(func() error {
 		return productVideoPublisher.Start(gctx)
 	})
+	group.Go(func() error {
+		return meter.Start(gctx)
+	})
+	group.Go(func() error {
+		return quotaTracker.Start(gctx)
+	})
+	group.Go(func() error {
+		return jobTracker.Start(gctx)
+	})
+	group.Go(func() error {
+		return videoFeedbackJob.New(relevantVideoRepo, cnf.VideoFeedback).Start(gctx)
+	})
+	group.Go(func() error {
+		return videoRefreshJob.New(relevantVideoRepo, videoSource, cnf.VideoRefresh).Start(gctx)
+	})
+	if cnf.API.Addr != "" {
+		group.Go(func() error {
+			return server.New(productRepo, reviewSentimentRepo, relevantVideoRepo, jobTracker, cnf.API).Serve(gctx, cnf.API.Addr)
+		})
+	}
+	if cnf.GRPC.Addr != "" {
+		group.Go(func() error {
+			return rpc.New(productRepo, reviewSentimentRepo, relevantVideoRepo, cnf.GRPC).Serve(gctx, cnf.GRPC.Addr)
+		})
+	}
+	if cnf.Metrics.Addr != "" {
+		group.Go(func() error {
+			return metrics.Serve(gctx, cnf.Metrics.Addr)
+		})
+	}
 
 	select {
 	case <-sigs:
@@ -127,3 +215,80 @@ func createFirestoreClientOrPanic(ctx context.Context, app *Firestore.App) datab
 	}
 	return database.New(firestoreClient)
 }
+
+// The cache is the outermost middleware, so that cache hits are neither metered nor rate limited.
+// The circuit breaker comes before the rate limit, so that the calls to a failing provider do not wait for it.
+func createProviderMiddlewaresOrPanic(cnf config.Config, llmCacheRepo llmCacheRepository.IRepository, meter *usage.Meter, tokenizer gptutils.Tokenizer) []provider.Middleware {
+	middlewares := []provider.Middleware{}
+
+	store, err := cache.NewStore(cnf.LLMCache, llmCacheRepo)
+	if err != nil {
+		panic(err)
+	}
+	if store != nil {
+		middlewares = append(middlewares, cache.Middleware(store, cnf.LLMCache.TTL))
+	}
+
+	middlewares = append(middlewares,
+		meter.Middleware(),
+		provider.CircuitBreakerMiddleware(),
+		provider.RateLimitMiddleware(cnf.LLM.RequestsPerMinute, cnf.LLM.TokensPerMinute, tokenizer))
+
+	return middlewares
+}
+
+// llmBreakers returns the breakers of the providers of the enrichment's chain, any of them can serve the enrichment.
+func llmBreakers(cnf config.Config, providerName string) []*breaker.Breaker {
+	breakers := []*breaker.Breaker{}
+	for _, target := range append([]string{providerName}, cnf.LLM.FallbackChain...) {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(target, "/")
+		breakers = append(breakers, breaker.LLM(name))
+	}
+	return breakers
+}
+
+// videoSourceBreakers returns the breakers of the video sources, any of them can serve the enrichment.
+// The catalog never fails, so the enrichment is not paused if it is a source.
+func videoSourceBreakers(cnf config.Config) []*breaker.Breaker {
+	breakers := []*breaker.Breaker{}
+	for _, name := range cnf.VideoSource.Sources {
+		switch strings.TrimSpace(name) {
+		case videosource.YouTube:
+			breakers = append(breakers, breaker.Get(breaker.YouTube))
+		case videosource.Vimeo:
+			breakers = append(breakers, breaker.Get(breaker.Vimeo))
+		case videosource.Catalog:
+			return nil
+		}
+	}
+	return breakers
+}
+
+func createGptFactoryOrPanic(cnf config.Config, providers *provider.Registry, providerName string) gpt.ClientFactory {
+	chain, err := gpt.ParseTargets(append([]string{providerName}, cnf.LLM.FallbackChain...), providers)
+	if err != nil {
+		panic(err)
+	}
+
+	tasks, err := gpt.ParseTaskChains(cnf.LLM.TaskModels, providers)
+	if err != nil {
+		panic(err)
+	}
+
+	gptFactory, err := gpt.NewClientFactory(chain, tasks, gptClientConfig())
+	if err != nil {
+		panic(err)
+	}
+	return gptFactory
+}
+
+// gptClientConfig is the config of the clients of every enrichment, including the experiments
+func gptClientConfig() gpt.ClientConfig {
+	return gpt.ClientConfig{
+		Temperature: utils.Float32ToPointer(0.1),
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"go-firestore-gpt/internal/config"
+	"go-firestore-gpt/internal/database"
+	"go-firestore-gpt/internal/experiment"
+	experimentsRepository "go-firestore-gpt/internal/repository/experiments"
+
+	Firestore "firebase.google.com/go/v4"
+
+	"google.golang.org/api/option"
+)
+
+// Summarizes the results of a prompt/model experiment, e.g.
+//
+//	go run ./report -experiment sentiment-prompt-v2
+func main() {
+
+	name := flag.String("experiment", "", "name of the experiment, defaults to EXPERIMENT_NAME")
+	flag.Parse()
+
+	cnf := config.LoadConfigOrPanic()
+	if *name == "" {
+		*name = cnf.Experiment.Name
+	}
+	if *name == "" {
+		panic("the experiment name is missing")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app := createFirestoreAppOrPanic(ctx, cnf.Firebase)
+	firestoreClient := createFirestoreClientOrPanic(ctx, app)
+	defer firestoreClient.Close()
+
+	results, err := experimentsRepository.New(&firestoreClient).ListByExperiment(ctx, *name)
+	if err != nil {
+		panic(err)
+	}
+
+	experiment.Summarize(*name, results).Print(os.Stdout)
+}
+
+func createFirestoreAppOrPanic(ctx context.Context, cnf config.Firebase) *Firestore.App {
+	FirestoreCreds, err := json.Marshal(cnf)
+	if err != nil {
+		panic(err)
+	}
+
+	sa := option.WithCredentialsJSON(FirestoreCreds)
+	app, err := Firestore.NewApp(ctx, nil, sa)
+	if err != nil {
+		panic(err)
+	}
+	return app
+}
+
+func createFirestoreClientOrPanic(ctx context.Context, app *Firestore.App) database.FirestoreClient {
+	firestoreClient, err := app.Firestore(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return database.New(firestoreClient)
+}